@@ -36,6 +36,11 @@ type Request struct {
 	Directory       string
 	ClearTpmOwner   bool
 	ClobberStateful bool
+
+	// ProgressInterval is how often the dut-agent should emit a
+	// ProgressEvent frame on stdout while a flash is in progress. Zero
+	// means use defaultProgressInterval.
+	ProgressInterval time.Duration
 }
 
 type Result struct {
@@ -17,6 +17,9 @@ import (
 	"chromium.googlesource.com/chromiumos/platform/dev-util.git/contrib/fflash/internal/progress"
 )
 
+// defaultProgressInterval is used when Request.ProgressInterval is unset.
+const defaultProgressInterval = time.Second
+
 func Main() error {
 	var r Request
 	if err := gob.NewDecoder(os.Stdin).Decode(&r); err != nil {
@@ -56,9 +59,17 @@ func Main() error {
 		ch <- r.FlashStateful(flashCtx, client, rw)
 	}(pr.NewWriter("stateful"))
 
+	// enc streams Frames to the driver side on stdout, throttled to
+	// r.ProgressInterval so a slow link isn't swamped with updates.
+	enc := gob.NewEncoder(os.Stdout)
+	progressInterval := r.ProgressInterval
+	if progressInterval <= 0 {
+		progressInterval = defaultProgressInterval
+	}
+
 	var failed bool
 	completed := 0
-	ticker := time.NewTicker(time.Second)
+	ticker := time.NewTicker(progressInterval)
 	for completed < 3 {
 		select {
 		case err := <-ch:
@@ -72,6 +83,14 @@ func Main() error {
 			completed += 1
 		case <-ticker.C:
 			log.Println("flash", pr.Report())
+			for _, s := range pr.Snapshots() {
+				enc.Encode(Frame{Progress: &ProgressEvent{
+					Name:    s.Name,
+					N:       s.N,
+					Total:   s.Total,
+					RateBps: s.RateBps,
+				}})
+			}
 		}
 	}
 	ticker.Stop()
@@ -95,5 +114,10 @@ func Main() error {
 		return fmt.Errorf("clear tpm owner failed: %w", err)
 	}
 
+	result := Result{}
+	if err := enc.Encode(Frame{Result: &result}); err != nil {
+		return fmt.Errorf("cannot send result: %w", err)
+	}
+
 	return nil
 }
@@ -4,33 +4,21 @@
 
 package dut
 
-import (
-	"time"
-
-	"golang.org/x/oauth2"
-)
-
-// Request contains everything needed to perform a flash.
-type Request struct {
-	// Base time when the flash started, for logging.
-	ElapsedTimeWhenSent time.Duration
-
-	Token     *oauth2.Token
-	Bucket    string
-	Directory string
-
-	FlashOptions
-}
-
-// FlashOptions for Request.
-// Unlike Request.Bucket, Request.Directory, these are determined solely by
-// parsing the command line without further processing.
-type FlashOptions struct {
-	ClobberStateful bool // whether to clobber the stateful partition
-	ClearTpmOwner   bool // whether to clean tpm owner on reboot
+// ProgressEvent reports the incremental progress of one partition being
+// flashed. The dut-agent emits a stream of these on stdout, one per
+// ReportingWriter per Request.ProgressInterval tick, so the driver side can
+// render a live view of the flash instead of staring at a blank terminal.
+type ProgressEvent struct {
+	Name    string // partition being flashed, e.g. "kernel", "rootfs", "stateful"
+	N       int64  // bytes fetched so far
+	Total   int64  // total bytes to fetch, as reported by Cloud Storage
+	RateBps float64
 }
 
-type Result struct {
-	RetryDisableRootfsVerification bool
-	RetryClearTpmOwner             bool
+// Frame is a single message on the dut-agent stdout protocol. Exactly one of
+// Progress or Result is set. A Frame carrying Result is always the last one
+// written, and callers should stop decoding once they see it.
+type Frame struct {
+	Progress *ProgressEvent
+	Result   *Result
 }
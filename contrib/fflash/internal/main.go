@@ -9,6 +9,7 @@ import (
 	"context"
 	"encoding/gob"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/user"
@@ -24,11 +25,16 @@ import (
 
 	"chromium.googlesource.com/chromiumos/platform/dev-util.git/contrib/fflash/internal/dut"
 	embeddedagent "chromium.googlesource.com/chromiumos/platform/dev-util.git/contrib/fflash/internal/embedded-agent"
+	"chromium.googlesource.com/chromiumos/platform/dev-util.git/contrib/fflash/internal/progress"
 	"chromium.googlesource.com/chromiumos/platform/dev-util.git/contrib/fflash/internal/ssh"
 )
 
 const devFeaturesRootfsVerification = "/usr/libexec/debugd/helpers/dev_features_rootfs_verification"
 
+// progressInterval is how often we ask the dut-agent to report flash
+// progress back to us.
+const progressInterval = time.Second
+
 // getToken returns the user's token to access Google Cloud Storage.
 // It reads ~/.boto, which is a ini file set up by `gsutil.py config`.
 func getToken(ctx context.Context) (oauth2.TokenSource, error) {
@@ -148,19 +154,25 @@ func Main(ctx context.Context, t0 time.Time, target string, opts *Options) error
 	}
 	var stdin bytes.Buffer
 	req.ElapsedTimeWhenSent = time.Since(t0)
+	req.ProgressInterval = progressInterval
 	if err := gob.NewEncoder(&stdin).Encode(req); err != nil {
 		return fmt.Errorf("failed to write flash request: %w", err)
 	}
 	session.Stdin = &stdin
-	var stdout bytes.Buffer
-	session.Stdout = &stdout
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("cannot set up dut-agent stdout: %w", err)
+	}
 	session.Stderr = os.Stderr
-	if err := session.Run(agentPath); err != nil {
-		return fmt.Errorf("dut-agent failed: %w", err)
+	if err := session.Start(agentPath); err != nil {
+		return fmt.Errorf("dut-agent failed to start: %w", err)
+	}
+	result, err := receiveResult(stdout)
+	if werr := session.Wait(); werr != nil {
+		return fmt.Errorf("dut-agent failed: %w", werr)
 	}
-	var result dut.Result
-	if err := gob.NewDecoder(&stdout).Decode(&result); err != nil {
-		return fmt.Errorf("cannot decode dut-agent result: %w", err)
+	if err != nil {
+		return err
 	}
 
 	oldParts, err := DetectPartitions(sshClient)
@@ -205,3 +217,46 @@ func Main(ctx context.Context, t0 time.Time, target string, opts *Options) error
 
 	return nil
 }
+
+// receiveResult decodes the stream of dut.Frame values read from r, one per
+// partition on each dut-agent progress tick, rendering a live multi-bar to stderr as they
+// arrive. It returns once the terminating frame carrying the dut.Result is
+// decoded.
+func receiveResult(r io.Reader) (dut.Result, error) {
+	dec := gob.NewDecoder(r)
+	bar := progress.NewMultiBar(os.Stderr)
+
+	var order []string
+	snapshots := map[string]progress.Snapshot{}
+
+	for {
+		var frame dut.Frame
+		if err := dec.Decode(&frame); err != nil {
+			if err == io.EOF {
+				return dut.Result{}, fmt.Errorf("dut-agent exited without sending a result")
+			}
+			return dut.Result{}, fmt.Errorf("cannot decode dut-agent frame: %w", err)
+		}
+
+		if p := frame.Progress; p != nil {
+			if _, seen := snapshots[p.Name]; !seen {
+				order = append(order, p.Name)
+			}
+			snapshots[p.Name] = progress.Snapshot{
+				Name:    p.Name,
+				N:       p.N,
+				Total:   p.Total,
+				RateBps: p.RateBps,
+			}
+
+			ordered := make([]progress.Snapshot, len(order))
+			for i, name := range order {
+				ordered[i] = snapshots[name]
+			}
+			bar.Render(ordered)
+			continue
+		}
+
+		return *frame.Result, nil
+	}
+}
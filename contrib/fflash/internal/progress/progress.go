@@ -121,17 +121,31 @@ func (r *ProgressReporter) Report() string {
 func (r *ProgressReporter) NewWriter(name string) *ReportingWriter {
 	rw := &ReportingWriter{
 		name: name,
+		rate: rate.NewEstimator(rateEstimationWindow),
 	}
 	r.sources = append(r.sources, rw)
 	return rw
 }
 
+// Snapshots returns the current (name, bytes done, bytes total, bps) of every
+// ReportingWriter registered with r, in the order they were created. It is
+// used to build progress events to send to a remote listener, as opposed to
+// Report() which is meant for local human-readable logging.
+func (r *ProgressReporter) Snapshots() []Snapshot {
+	snapshots := make([]Snapshot, len(r.sources))
+	for i, w := range r.sources {
+		snapshots[i] = w.Snapshot()
+	}
+	return snapshots
+}
+
 // ReportingWriter is an io.Writer which reports its progress to a ProgressWriter.
 type ReportingWriter struct {
 	mutex sync.Mutex
 	name  string
 	n     int64
 	total int64
+	rate  *rate.Estimator
 }
 
 var _ io.Writer = &ReportingWriter{}
@@ -158,3 +172,25 @@ func (w *ReportingWriter) Stats() (stats string, n, total int64) {
 
 	return fmt.Sprintf("[%s %s]", w.name, formatSize2(w.n, w.total)), w.n, w.total
 }
+
+// Snapshot is a point-in-time reading of a ReportingWriter's progress,
+// suitable for sending to a remote listener over the wire.
+type Snapshot struct {
+	Name    string
+	N       int64
+	Total   int64
+	RateBps float64
+}
+
+// Snapshot returns the current progress of w.
+func (w *ReportingWriter) Snapshot() Snapshot {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return Snapshot{
+		Name:    w.name,
+		N:       w.n,
+		Total:   w.total,
+		RateBps: w.rate.AddRecord(float64(w.n * 8)),
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const barWidth = 20
+
+// MultiBar renders a set of named progress bars to w (typically os.Stderr),
+// redrawing the previous frame in place on each call to Render. It is used by
+// the driver side to turn a stream of per-partition progress events from the
+// dut-agent into a live view of the flash.
+type MultiBar struct {
+	w        io.Writer
+	numLines int
+}
+
+// NewMultiBar creates a MultiBar writing to w.
+func NewMultiBar(w io.Writer) *MultiBar {
+	return &MultiBar{w: w}
+}
+
+// Render draws one line per snapshot, in the order given, overwriting
+// whatever this MultiBar last drew.
+func (b *MultiBar) Render(snapshots []Snapshot) {
+	if b.numLines > 0 {
+		fmt.Fprintf(b.w, "\033[%dA", b.numLines)
+	}
+	for _, s := range snapshots {
+		fmt.Fprintf(b.w, "\033[2K[%-8s] %s  %5.1f%%  %sbps  ETA %s\n",
+			s.Name,
+			bar(s.N, s.Total),
+			percent(s.N, s.Total),
+			formatUnit(s.RateBps),
+			formatETA(s),
+		)
+	}
+	b.numLines = len(snapshots)
+}
+
+func percent(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(total)
+}
+
+func bar(n, total int64) string {
+	filled := 0
+	if total > 0 {
+		filled = int(float64(barWidth) * float64(n) / float64(total))
+		if filled > barWidth {
+			filled = barWidth
+		}
+	}
+	return strings.Repeat("#", filled) + strings.Repeat("-", barWidth-filled)
+}
+
+// formatETA estimates the remaining time to finish a Snapshot from its
+// current rate, or "--:--" if that cannot yet be estimated.
+func formatETA(s Snapshot) string {
+	remaining := s.Total - s.N
+	if s.Total == 0 || remaining <= 0 || s.RateBps <= 0 {
+		return "--:--"
+	}
+	eta := time.Duration(float64(remaining*8) / s.RateBps * float64(time.Second))
+	return eta.Round(time.Second).String()
+}
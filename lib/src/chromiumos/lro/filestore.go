@@ -0,0 +1,184 @@
+// Copyright 2023 The Chromium OS Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package lro
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"go.chromium.org/chromiumos/config/go/api/test/tls/dependencies/longrunning"
+)
+
+// fileStoreOp identifies the kind of record appended to a FileStore's WAL.
+type fileStoreOp int
+
+const (
+	fileStoreOpPut fileStoreOp = iota
+	fileStoreOpDelete
+)
+
+// fileStoreRecord is a single entry in a FileStore's write-ahead log.
+type fileStoreRecord struct {
+	Op         fileStoreOp
+	Name       string
+	FinishTime time.Time
+	OpProto    []byte // proto.Marshal(*longrunning.Operation); unset for fileStoreOpDelete
+}
+
+// FileStore is a Store backed by an append-only write-ahead log on disk.
+// Put and Delete only ever append a record, so a crash mid-write leaves the
+// log truncated at a record boundary rather than corrupting previously
+// durable entries; the in-memory index is rebuilt by replaying the log when
+// the FileStore is opened.
+type FileStore struct {
+	f     *os.File
+	index *InMemoryStore // reuses InMemoryStore's locking, index and watchHub
+}
+
+var _ Store = (*FileStore)(nil)
+
+// OpenFileStore opens (creating if necessary) the write-ahead log at path
+// and replays it to rebuild the in-memory index.
+func OpenFileStore(path string) (*FileStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("lro: open file store %s: %w", path, err)
+	}
+	fs := &FileStore{
+		f:     f,
+		index: NewInMemoryStore(),
+	}
+	if err := fs.replay(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return fs, nil
+}
+
+// countingReader wraps an io.Reader and tracks how many bytes have been
+// read through it, so replay can recover the file offset immediately after
+// the last successfully-decoded record.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// ReadByte makes countingReader an io.ByteReader, so gob reads exactly the
+// bytes of each record instead of wrapping it in a read-ahead buffer that
+// would throw off n.
+func (cr *countingReader) ReadByte() (byte, error) {
+	var b [1]byte
+	if _, err := io.ReadFull(cr, b[:]); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// replay reads every record in the WAL from the start and applies it to the
+// in-memory index, stopping at the first incomplete or corrupt trailing
+// record left by a crash mid-append. That tail is truncated off the file so
+// later appends land right after the last good record instead of behind a
+// permanently undecodable one.
+func (fs *FileStore) replay() error {
+	if _, err := fs.f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("lro: seek file store: %w", err)
+	}
+	cr := &countingReader{r: fs.f}
+	for {
+		// Every record was written by its own gob.Encoder (see append), so
+		// it carries its own type definitions and needs its own decoder.
+		good := cr.n
+		var rec fileStoreRecord
+		if err := gob.NewDecoder(cr).Decode(&rec); err != nil {
+			if err == io.EOF {
+				break
+			}
+			// A partial or corrupt final record from a crash mid-append is
+			// expected and not fatal: everything durable before it already
+			// landed in the index. Truncate it off so the log doesn't get
+			// stuck re-failing to decode the same tail on every restart.
+			log.Printf("lro: file store: discarding undecodable tail at offset %d: %v", good, err)
+			if err := fs.f.Truncate(good); err != nil {
+				return fmt.Errorf("lro: truncate file store tail: %w", err)
+			}
+			break
+		}
+		switch rec.Op {
+		case fileStoreOpDelete:
+			fs.index.Delete(rec.Name)
+		case fileStoreOpPut:
+			var op longrunning.Operation
+			if err := proto.Unmarshal(rec.OpProto, &op); err != nil {
+				return fmt.Errorf("lro: replay file store: unmarshal %s: %w", rec.Name, err)
+			}
+			fs.index.Put(rec.Name, &op, rec.FinishTime)
+		}
+	}
+	_, err := fs.f.Seek(0, io.SeekEnd)
+	return err
+}
+
+// append writes rec to the WAL and fsyncs it. Put and Delete call it before
+// applying rec to the in-memory index, so they never report success for a
+// record that isn't durable yet.
+func (fs *FileStore) append(rec fileStoreRecord) error {
+	if err := gob.NewEncoder(fs.f).Encode(rec); err != nil {
+		return fmt.Errorf("lro: append file store record: %w", err)
+	}
+	if err := fs.f.Sync(); err != nil {
+		return fmt.Errorf("lro: sync file store: %w", err)
+	}
+	return nil
+}
+
+func (fs *FileStore) Put(name string, op *longrunning.Operation, finishTime time.Time) error {
+	b, err := proto.Marshal(op)
+	if err != nil {
+		return fmt.Errorf("lro: marshal operation %s: %w", name, err)
+	}
+	if err := fs.append(fileStoreRecord{Op: fileStoreOpPut, Name: name, FinishTime: finishTime, OpProto: b}); err != nil {
+		return err
+	}
+	return fs.index.Put(name, op, finishTime)
+}
+
+func (fs *FileStore) Get(name string) (*longrunning.Operation, time.Time, error) {
+	return fs.index.Get(name)
+}
+
+func (fs *FileStore) Delete(name string) error {
+	if err := fs.append(fileStoreRecord{Op: fileStoreOpDelete, Name: name}); err != nil {
+		return err
+	}
+	return fs.index.Delete(name)
+}
+
+func (fs *FileStore) List() ([]StoredOperation, error) {
+	return fs.index.List()
+}
+
+func (fs *FileStore) ListExpired(olderThan time.Time) ([]string, error) {
+	return fs.index.ListExpired(olderThan)
+}
+
+func (fs *FileStore) Watch(name string) <-chan struct{} {
+	return fs.index.Watch(name)
+}
+
+// Close closes the underlying WAL file.
+func (fs *FileStore) Close() error {
+	return fs.f.Close()
+}
@@ -0,0 +1,160 @@
+// Copyright 2023 The Chromium OS Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package lro
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.chromium.org/chromiumos/config/go/api/test/tls/dependencies/longrunning"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultListPageSize is used when ListOperationsRequest.PageSize is unset.
+const defaultListPageSize = 100
+
+// operationFilter is a parsed AIP-160 style filter expression accepted by
+// ListOperations. Only a small, AND-only subset of the filter language is
+// supported, which is all callers of this package currently need:
+//
+//	done=true|false
+//	name:prefix
+//	finish_time>RFC3339-timestamp
+//
+// Clauses are combined with implicit AND; unrecognized clauses are rejected
+// rather than silently ignored.
+type operationFilter struct {
+	done            *bool
+	namePrefix      string
+	finishTimeAfter *time.Time
+}
+
+func parseOperationFilter(filter string) (operationFilter, error) {
+	var f operationFilter
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return f, nil
+	}
+	for _, clause := range strings.Fields(filter) {
+		switch {
+		case strings.HasPrefix(clause, "done="):
+			v, err := strconv.ParseBool(strings.TrimPrefix(clause, "done="))
+			if err != nil {
+				return operationFilter{}, fmt.Errorf("invalid done clause %q: %w", clause, err)
+			}
+			f.done = &v
+		case strings.HasPrefix(clause, "name:"):
+			f.namePrefix = strings.TrimPrefix(clause, "name:")
+		case strings.HasPrefix(clause, "finish_time>"):
+			t, err := time.Parse(time.RFC3339, strings.TrimPrefix(clause, "finish_time>"))
+			if err != nil {
+				return operationFilter{}, fmt.Errorf("invalid finish_time clause %q: %w", clause, err)
+			}
+			f.finishTimeAfter = &t
+		default:
+			return operationFilter{}, fmt.Errorf("unsupported filter clause %q", clause)
+		}
+	}
+	return f, nil
+}
+
+func (f operationFilter) matches(so StoredOperation) bool {
+	if f.done != nil && so.Op.Done != *f.done {
+		return false
+	}
+	if f.namePrefix != "" && !strings.HasPrefix(so.Op.Name, f.namePrefix) {
+		return false
+	}
+	if f.finishTimeAfter != nil && !so.FinishTime.After(*f.finishTimeAfter) {
+		return false
+	}
+	return true
+}
+
+// listPageToken is the state encoded into ListOperationsResponse's
+// NextPageToken. It carries the filter that produced it so a client can't
+// resume a page with a different filter and get results spliced from two
+// different queries.
+type listPageToken struct {
+	LastName string
+	Filter   string
+}
+
+func encodeListPageToken(t listPageToken) string {
+	return base64.URLEncoding.EncodeToString([]byte(t.LastName + "\x00" + t.Filter))
+}
+
+func decodeListPageToken(s string) (listPageToken, error) {
+	if s == "" {
+		return listPageToken{}, nil
+	}
+	raw, err := base64.URLEncoding.DecodeString(s)
+	if err != nil {
+		return listPageToken{}, fmt.Errorf("invalid page_token")
+	}
+	parts := strings.SplitN(string(raw), "\x00", 2)
+	if len(parts) != 2 {
+		return listPageToken{}, fmt.Errorf("invalid page_token")
+	}
+	return listPageToken{LastName: parts[0], Filter: parts[1]}, nil
+}
+
+// ListOperations lists operations matching req.Filter (see
+// parseOperationFilter), ordered deterministically by name and paginated
+// with an opaque page_token.
+func (m *Manager) ListOperations(ctx context.Context, req *longrunning.ListOperationsRequest) (*longrunning.ListOperationsResponse, error) {
+	filter, err := parseOperationFilter(req.Filter)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "lro ListOperations: %s", err)
+	}
+
+	token, err := decodeListPageToken(req.PageToken)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "lro ListOperations: %s", err)
+	}
+	if req.PageToken != "" && token.Filter != req.Filter {
+		return nil, status.Error(codes.InvalidArgument, "lro ListOperations: page_token was issued for a different filter")
+	}
+
+	stored, err := m.store.List()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "lro ListOperations: %s", err)
+	}
+
+	var matched []*longrunning.Operation
+	for _, so := range stored {
+		if filter.matches(so) {
+			matched = append(matched, so.Op)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Name < matched[j].Name })
+
+	start := 0
+	if token.LastName != "" {
+		start = sort.Search(len(matched), func(i int) bool { return matched[i].Name > token.LastName })
+	}
+
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = defaultListPageSize
+	}
+	end := start + pageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+	page := matched[start:end]
+
+	resp := &longrunning.ListOperationsResponse{Operations: page}
+	if end < len(matched) {
+		resp.NextPageToken = encodeListPageToken(listPageToken{LastName: page[len(page)-1].Name, Filter: req.Filter})
+	}
+	return resp, nil
+}
@@ -0,0 +1,87 @@
+package lro_test
+
+import (
+	"context"
+	"testing"
+
+	"chromiumos/lro"
+
+	"go.chromium.org/chromiumos/config/go/api/test/tls/dependencies/longrunning"
+)
+
+func TestListOperationsFilterAndPagination(t *testing.T) {
+	m := lro.New()
+	defer m.Close()
+
+	var names []string
+	for i := 0; i < 5; i++ {
+		op := m.NewOperation()
+		names = append(names, op.Name)
+	}
+	// Mark a couple of operations done so the done=true filter has
+	// something to find.
+	if err := m.SetResult(names[0], &longrunning.Operation{}); err != nil {
+		t.Fatalf("SetResult(%s) failed: %s", names[0], err)
+	}
+	if err := m.SetResult(names[1], &longrunning.Operation{}); err != nil {
+		t.Fatalf("SetResult(%s) failed: %s", names[1], err)
+	}
+
+	resp, err := m.ListOperations(context.Background(), &longrunning.ListOperationsRequest{
+		Filter: "done=true",
+	})
+	if err != nil {
+		t.Fatalf("ListOperations failed: %s", err)
+	}
+	if len(resp.Operations) != 2 {
+		t.Errorf("ListOperations(done=true) returned %d operations; want 2", len(resp.Operations))
+	}
+
+	// Paginate through everything with page size 2 and check the pages
+	// together hold as many operations as were created.
+	var seen []string
+	pageToken := ""
+	for {
+		resp, err := m.ListOperations(context.Background(), &longrunning.ListOperationsRequest{
+			PageSize:  2,
+			PageToken: pageToken,
+		})
+		if err != nil {
+			t.Fatalf("ListOperations failed: %s", err)
+		}
+		for _, op := range resp.Operations {
+			seen = append(seen, op.Name)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		pageToken = resp.NextPageToken
+	}
+	if len(seen) != len(names) {
+		t.Errorf("paginated ListOperations returned %d operations; want %d", len(seen), len(names))
+	}
+}
+
+func TestListOperationsRejectsFilterMismatchedPageToken(t *testing.T) {
+	m := lro.New()
+	defer m.Close()
+	// Two operations with page size 1, so the first page has a
+	// NextPageToken to resume with.
+	m.NewOperation()
+	m.NewOperation()
+
+	resp, err := m.ListOperations(context.Background(), &longrunning.ListOperationsRequest{
+		Filter:   "done=false",
+		PageSize: 1,
+	})
+	if err != nil {
+		t.Fatalf("ListOperations failed: %s", err)
+	}
+
+	if _, err := m.ListOperations(context.Background(), &longrunning.ListOperationsRequest{
+		Filter:    "done=true",
+		PageToken: resp.NextPageToken,
+	}); err == nil {
+		t.Errorf("ListOperations with mismatched filter/page_token succeeded; want error")
+	}
+}
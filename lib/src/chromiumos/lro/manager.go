@@ -9,6 +9,7 @@ package lro
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"sync"
 	"time"
@@ -22,13 +23,6 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-// operation is used by Manager to hold extra metadata.
-type operation struct {
-	op         *longrunning.Operation
-	finishTime time.Time
-	done       chan struct{}
-}
-
 // Manager keeps track of longrunning operations and serves operations related requests.
 // Manager implements longrunning.OperationsServer.
 // Manager is safe to use concurrently.
@@ -37,18 +31,46 @@ type Manager struct {
 	mu sync.Mutex
 	// Provide stubs for unimplemented methods
 	longrunning.UnimplementedOperationsServer
-	// Mapping of operation name to operation.
-	operations map[string]*operation
+	// store persists the operations themselves; done only tracks the
+	// channels WaitOperation blocks on, which cannot be serialized.
+	store Store
+	// done holds, for every operation store knows about, a channel that is
+	// closed once the operation finishes.
+	done map[string]chan struct{}
 	// expiryStopper signals the expiration goroutine to terminate.
 	expiryStopper chan struct{}
 }
 
-// New returns a new Manager which must be closed after use.
+// New returns a new Manager which must be closed after use. Operations are
+// kept in memory only; use NewWithStore for a Manager that survives a
+// restart.
 func New() *Manager {
+	return NewWithStore(NewInMemoryStore())
+}
+
+// NewWithStore returns a new Manager persisting operations to store, which
+// must be closed after use. Any operation already in store that isn't done
+// yet has its wait channel rebuilt, so a WaitOperation started before a
+// restart picks up where it left off once the client retries.
+func NewWithStore(store Store) *Manager {
 	m := &Manager{
-		operations:    make(map[string]*operation),
+		store:         store,
+		done:          make(map[string]chan struct{}),
 		expiryStopper: make(chan struct{}),
 	}
+
+	stored, err := store.List()
+	if err != nil {
+		log.Printf("lro New: failed to load operations from store: %s", err)
+	}
+	for _, so := range stored {
+		ch := make(chan struct{})
+		if so.Op.Done {
+			close(ch)
+		}
+		m.done[so.Op.Name] = ch
+	}
+
 	go func() {
 		for {
 			select {
@@ -65,6 +87,11 @@ func New() *Manager {
 // Close will close the Manager.
 func (m *Manager) Close() {
 	close(m.expiryStopper)
+	if c, ok := m.store.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			log.Printf("lro Close: failed to close store: %s", err)
+		}
+	}
 }
 
 // NewOperation returns a new longrunning.Operation managed by Manager.
@@ -74,49 +101,53 @@ func (m *Manager) NewOperation() *longrunning.Operation {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	name := "operations/" + uuid.New().String()
-	if _, ok := m.operations[name]; ok {
+	if _, ok := m.done[name]; ok {
 		panic("Generated a duplicate UUID, likely due to RNG issue.")
 	}
-	m.operations[name] = &operation{
-		op: &longrunning.Operation{
-			Name: name,
-		},
-		done: make(chan struct{}),
+	op := &longrunning.Operation{Name: name}
+	if err := m.store.Put(name, op, time.Time{}); err != nil {
+		panic(fmt.Sprintf("lro NewOperation: failed to persist %s: %s", name, err))
 	}
-	return m.operations[name].op
+	m.done[name] = make(chan struct{})
+	return op
 }
 
 func (m *Manager) delete(name string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if _, ok := m.operations[name]; !ok {
+	ch, ok := m.done[name]
+	if !ok {
 		return fmt.Errorf("lro delete: unknown name %s", name)
 	}
-	if !m.operations[name].op.Done {
-		close(m.operations[name].done)
+	select {
+	case <-ch:
+		// Already done; nothing to close.
+	default:
+		close(ch)
+	}
+	if err := m.store.Delete(name); err != nil {
+		return fmt.Errorf("lro delete: %w", err)
 	}
-	delete(m.operations, name)
+	delete(m.done, name)
 	return nil
 }
 
 func (m *Manager) deleteExpiredOperations() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	for name, operation := range m.operations {
-		// Don't do anything for an Operation which isn't done.
-		if !operation.op.Done {
+	// Remove operations that have been done for more than 30 days.
+	names, err := m.store.ListExpired(time.Now().Add(-30 * 24 * time.Hour))
+	if err != nil {
+		log.Printf("lro deleteExpiredOperations: failed to list expired operations: %s", err)
+		return
+	}
+	for _, name := range names {
+		log.Printf("lro deleteExpiredOperations: deleting expired %s", name)
+		if err := m.store.Delete(name); err != nil {
+			log.Printf("lro deleteExpiredOperations: failed to delete %s: %s", name, err)
 			continue
 		}
-		// If finish time is nil, panic as it should have been set when done.
-		if operation.finishTime.IsZero() {
-			panic(fmt.Sprintf("Missing finishTime for %s", name))
-		}
-		// Remove the Operation after 30 days of being done.
-		expire := operation.finishTime.Add(30 * 24 * time.Hour)
-		if time.Now().After(expire) {
-			log.Printf("lro deleteExpiredOperations: deleting expired %s", name)
-			delete(m.operations, name)
-		}
+		delete(m.done, name)
 	}
 }
 
@@ -126,22 +157,25 @@ func (m *Manager) deleteExpiredOperations() {
 func (m *Manager) SetResult(name string, resp proto.Message) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if _, ok := m.operations[name]; !ok {
+	op, _, err := m.store.Get(name)
+	if err != nil {
 		return fmt.Errorf("lro SetResult: unknown name %s", name)
 	}
-	if m.operations[name].op.Done {
+	if op.Done {
 		return fmt.Errorf("lro SetResult: name %s is already done", name)
 	}
 	a, err := ptypes.MarshalAny(resp)
 	if err != nil {
 		return err
 	}
-	m.operations[name].op.Result = &longrunning.Operation_Response{
+	op.Result = &longrunning.Operation_Response{
 		Response: a,
 	}
-	m.operations[name].finishTime = time.Now()
-	m.operations[name].op.Done = true
-	close(m.operations[name].done)
+	op.Done = true
+	if err := m.store.Put(name, op, time.Now()); err != nil {
+		return fmt.Errorf("lro SetResult: %w", err)
+	}
+	close(m.done[name])
 	return nil
 }
 
@@ -151,34 +185,62 @@ func (m *Manager) SetResult(name string, resp proto.Message) error {
 func (m *Manager) SetError(name string, opErr *status.Status) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if _, ok := m.operations[name]; !ok {
+	op, _, err := m.store.Get(name)
+	if err != nil {
 		return fmt.Errorf("lro SetError: unknown name %s", name)
 	}
-	if m.operations[name].op.Done {
+	if op.Done {
 		return fmt.Errorf("lro SetError: name %s is already done", name)
 	}
 	s := opErr.Proto()
-	m.operations[name].op.Result = &longrunning.Operation_Error{
+	op.Result = &longrunning.Operation_Error{
 		Error: &longrunning.Status{
 			Code:    s.GetCode(),
 			Message: s.GetMessage(),
 			Details: s.GetDetails(),
 		},
 	}
-	m.operations[name].finishTime = time.Now()
-	m.operations[name].op.Done = true
-	close(m.operations[name].done)
+	op.Done = true
+	if err := m.store.Put(name, op, time.Now()); err != nil {
+		return fmt.Errorf("lro SetError: %w", err)
+	}
+	close(m.done[name])
+	return nil
+}
+
+// SetMetadata updates the Metadata of an operation that is not yet done,
+// without marking it done. Callers that run for a long time (e.g. a
+// servod.Supervisor) use this to surface state transitions while they
+// continue running, ahead of the terminal SetResult/SetError call.
+func (m *Manager) SetMetadata(name string, md proto.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	op, _, err := m.store.Get(name)
+	if err != nil {
+		return fmt.Errorf("lro SetMetadata: unknown name %s", name)
+	}
+	if op.Done {
+		return fmt.Errorf("lro SetMetadata: name %s is already done", name)
+	}
+	a, err := ptypes.MarshalAny(md)
+	if err != nil {
+		return err
+	}
+	op.Metadata = a
+	if err := m.store.Put(name, op, time.Time{}); err != nil {
+		return fmt.Errorf("lro SetMetadata: %w", err)
+	}
 	return nil
 }
 
 func (m *Manager) getOperationClone(name string) (*longrunning.Operation, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	v, ok := m.operations[name]
-	if !ok {
+	op, _, err := m.store.Get(name)
+	if err != nil {
 		return nil, status.Errorf(codes.NotFound, "name %s does not exist", name)
 	}
-	return proto.Clone(v.op).(*longrunning.Operation), nil
+	return op, nil
 }
 
 // GetOperation returns the longrunning.Operation if managed.
@@ -198,11 +260,8 @@ func (m *Manager) DeleteOperation(ctx context.Context, req *longrunning.DeleteOp
 func (m *Manager) getOperationChannel(name string) (chan struct{}, bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	v, ok := m.operations[name]
-	if !ok {
-		return nil, ok
-	}
-	return v.done, ok
+	ch, ok := m.done[name]
+	return ch, ok
 }
 
 // WaitOperation returns once the longrunning.Operation is done or timeout.
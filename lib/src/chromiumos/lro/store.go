@@ -0,0 +1,169 @@
+// Copyright 2023 The Chromium OS Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package lro
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"go.chromium.org/chromiumos/config/go/api/test/tls/dependencies/longrunning"
+)
+
+// ErrNotFound is returned by a Store when asked for an operation it does not
+// have.
+var ErrNotFound = errors.New("lro: operation not found")
+
+// StoredOperation is a longrunning.Operation plus the bookkeeping Manager
+// needs but which doesn't belong on the proto itself.
+type StoredOperation struct {
+	Op         *longrunning.Operation
+	FinishTime time.Time
+}
+
+// Store is the persistence backend for Manager. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// Put persists op under name, along with the time it finished (the zero
+	// time if it isn't done yet), overwriting any existing entry.
+	Put(name string, op *longrunning.Operation, finishTime time.Time) error
+	// Get returns the persisted operation and its finish time, or
+	// ErrNotFound if name is not known to the store.
+	Get(name string) (*longrunning.Operation, time.Time, error)
+	// Delete removes the persisted operation. It does not return an error
+	// if name is not known to the store.
+	Delete(name string) error
+	// List returns every operation currently in the store, for Manager to
+	// rebuild its in-memory bookkeeping from at startup.
+	List() ([]StoredOperation, error)
+	// ListExpired returns the names of done operations whose finish time is
+	// before olderThan.
+	ListExpired(olderThan time.Time) ([]string, error)
+	// Watch returns a channel that receives a value when the operation
+	// named name is Put, and is closed when it is Deleted. The channel
+	// holds at most one pending value, so several Puts the watcher has not
+	// yet received may arrive as a single value.
+	Watch(name string) <-chan struct{}
+}
+
+// watchHub multiplexes Watch subscriptions by operation name. It is shared
+// by the Store implementations in this package.
+type watchHub struct {
+	mu   sync.Mutex
+	subs map[string][]chan struct{}
+}
+
+func newWatchHub() *watchHub {
+	return &watchHub{subs: make(map[string][]chan struct{})}
+}
+
+func (h *watchHub) Watch(name string) <-chan struct{} {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	h.subs[name] = append(h.subs[name], ch)
+	h.mu.Unlock()
+	return ch
+}
+
+// notify wakes up every subscriber of name. It never blocks: a subscriber
+// that isn't ready to receive simply misses this particular notification.
+func (h *watchHub) notify(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ch := range h.subs[name] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// closeAll closes and forgets every subscriber of name.
+func (h *watchHub) closeAll(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ch := range h.subs[name] {
+		close(ch)
+	}
+	delete(h.subs, name)
+}
+
+// InMemoryStore is the Store used by New. It keeps every operation in
+// memory, so a restart of the process loses all state; use a durable Store
+// such as FileStore where that matters.
+type InMemoryStore struct {
+	mu  sync.Mutex
+	ops map[string]StoredOperation
+	hub *watchHub
+}
+
+var _ Store = (*InMemoryStore)(nil)
+
+// NewInMemoryStore returns a new, empty InMemoryStore.
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		ops: make(map[string]StoredOperation),
+		hub: newWatchHub(),
+	}
+}
+
+func (s *InMemoryStore) Put(name string, op *longrunning.Operation, finishTime time.Time) error {
+	s.mu.Lock()
+	s.ops[name] = StoredOperation{
+		Op:         proto.Clone(op).(*longrunning.Operation),
+		FinishTime: finishTime,
+	}
+	s.mu.Unlock()
+	s.hub.notify(name)
+	return nil
+}
+
+func (s *InMemoryStore) Get(name string) (*longrunning.Operation, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	so, ok := s.ops[name]
+	if !ok {
+		return nil, time.Time{}, ErrNotFound
+	}
+	return proto.Clone(so.Op).(*longrunning.Operation), so.FinishTime, nil
+}
+
+func (s *InMemoryStore) Delete(name string) error {
+	s.mu.Lock()
+	delete(s.ops, name)
+	s.mu.Unlock()
+	s.hub.closeAll(name)
+	return nil
+}
+
+func (s *InMemoryStore) List() ([]StoredOperation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]StoredOperation, 0, len(s.ops))
+	for _, so := range s.ops {
+		out = append(out, StoredOperation{
+			Op:         proto.Clone(so.Op).(*longrunning.Operation),
+			FinishTime: so.FinishTime,
+		})
+	}
+	return out, nil
+}
+
+func (s *InMemoryStore) ListExpired(olderThan time.Time) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for name, so := range s.ops {
+		if so.Op.Done && !so.FinishTime.IsZero() && so.FinishTime.Before(olderThan) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (s *InMemoryStore) Watch(name string) <-chan struct{} {
+	return s.hub.Watch(name)
+}
@@ -0,0 +1,201 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"sync"
+
+	"chromiumos/test/dut/cmd/cros-dut/dutssh"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Architectures the embedded cache-agent is built for, matching fflash's
+// embedded-agent package.
+const (
+	archAarch64 = "aarch64"
+	archX8664   = "x86_64"
+)
+
+// ErrDUTAgentUnsupported is returned by PrepareDUTAgent when the DUT won't
+// allow an exec-mounted tmpfs (e.g. a restrictive mount policy). Callers
+// should fall back to streaming artifacts through the cacheserver process,
+// same as before this existed.
+var ErrDUTAgentUnsupported = errors.New("cache-agent unsupported on this DUT: exec tmpfs mount denied")
+
+// ErrDUTAgentNotEmbedded is returned by PrepareDUTAgent for every arch,
+// because no cache-agent binary is embedded into cacheserver yet (see
+// agentBinaryForArch). Callers should treat it the same way as
+// ErrDUTAgentUnsupported: fall back to streaming through the cacheserver
+// process.
+var ErrDUTAgentNotEmbedded = errors.New("cache-agent not embedded into cacheserver yet")
+
+// dutAgentInfo records where the cache-agent landed on a DUT and which
+// tmpfs mount it lives in, so DutAgentManager can skip re-pushing it and
+// can unmount it on shutdown.
+type dutAgentInfo struct {
+	path   string
+	tmpfs  string
+	sha256 string
+}
+
+// DutAgentManager pushes a compressed, statically-linked cache-agent onto a
+// DUT's tmpfs on first request for that DUT, and reuses the pushed agent on
+// subsequent calls. This is meant to let large artifact fetches (firmware
+// bundles, ash zips) decompress straight onto the DUT's disk via the on-DUT
+// agent instead of transiting the cacheserver process a second time.
+//
+// Follow-up (b/cache-agent): this is prototype-stage and not wired into the
+// fetch path yet, in two separate ways:
+//  1. PrepareDUTAgent is currently a stub: no cache-agent binary is embedded
+//     for any arch yet (see agentBinaryForArch), so every call fails with
+//     ErrDUTAgentNotEmbedded until the agent binaries are built and embedded.
+//  2. Even once an agent can be pushed, none of the existing artifact
+//     handlers (cacheGSHandler, staticHandler, stageHandler, ...) call
+//     PrepareDUTAgent or route a fetch through the pushed agent - only the
+//     standalone prepareDUTAgentHandler RPC exists so far.
+type DutAgentManager struct {
+	mu     sync.Mutex
+	agents map[string]dutAgentInfo // keyed by dutAddr
+}
+
+// NewDutAgentManager returns an empty DutAgentManager.
+func NewDutAgentManager() *DutAgentManager {
+	return &DutAgentManager{
+		agents: make(map[string]dutAgentInfo),
+	}
+}
+
+// agentBinaryForArch is meant to hold the xz-compressed cache-agent
+// executable for each supported architecture, the same way fflash embeds
+// its per-arch agent. TODO(b/cache-agent): nothing populates this yet -
+// there is no //go:embed directive and no build rule producing the
+// per-arch cache-agent artifacts, so PrepareDUTAgent is a stub that always
+// returns ErrDUTAgentNotEmbedded until that's wired up.
+var agentBinaryForArch = map[string][]byte{}
+
+// PrepareDUTAgent pushes (or reuses a previously pushed) cache-agent on the
+// DUT at dutAddr and returns the path to the pushed executable. It caches
+// the (dutAddr, sha256) pair so a second call for the same DUT and the same
+// agent binary is a no-op. Returns ErrDUTAgentUnsupported if the DUT denies
+// an exec-mounted tmpfs, or ErrDUTAgentNotEmbedded if no cache-agent binary
+// has been embedded for arch (currently true for every arch; see
+// agentBinaryForArch).
+func (m *DutAgentManager) PrepareDUTAgent(dutAddr, arch string) (string, error) {
+	b, ok := agentBinaryForArch[arch]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrDUTAgentNotEmbedded, arch)
+	}
+	sum := sha256.Sum256(b)
+	digest := hex.EncodeToString(sum[:])
+
+	m.mu.Lock()
+	if info, ok := m.agents[dutAddr]; ok && info.sha256 == digest {
+		m.mu.Unlock()
+		return info.path, nil
+	}
+	m.mu.Unlock()
+
+	client, err := ssh.Dial("tcp", dutAddr, dutssh.GetSSHConfig())
+	if err != nil {
+		return "", fmt.Errorf("failed to dial DUT %v: %w", dutAddr, err)
+	}
+	defer client.Close()
+
+	agentPath, tmpfs, err := pushCompressedAgent(client, b)
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	m.agents[dutAddr] = dutAgentInfo{path: agentPath, tmpfs: tmpfs, sha256: digest}
+	m.mu.Unlock()
+
+	return agentPath, nil
+}
+
+// Close unmounts every tmpfs this manager pushed an agent into. Failures
+// are logged and otherwise ignored, since this only runs on shutdown and a
+// DUT that's gone already took its tmpfs with it.
+func (m *DutAgentManager) Close() {
+	m.mu.Lock()
+	agents := m.agents
+	m.agents = make(map[string]dutAgentInfo)
+	m.mu.Unlock()
+
+	for dutAddr, info := range agents {
+		if info.tmpfs == "" {
+			continue
+		}
+		client, err := ssh.Dial("tcp", dutAddr, dutssh.GetSSHConfig())
+		if err != nil {
+			log.Printf("cacheserver: could not reach %v to clean up cache-agent tmpfs: %v", dutAddr, err)
+			continue
+		}
+		if _, err := runRemoteOutput(client, "umount "+info.tmpfs); err != nil {
+			log.Printf("cacheserver: failed to unmount %v on %v: %v", info.tmpfs, dutAddr, err)
+		}
+		client.Close()
+	}
+}
+
+// pushCompressedAgent pushes the xz-compressed executable b onto client's
+// remote host and returns the path of the pushed executable and the tmpfs
+// mountpoint it lives in. It mirrors fflash's PushCompressedExecutable: it
+// mounts a tmpfs with exec permissions so the agent can be run directly
+// from it. If the DUT denies remounting /tmp for exec, it returns
+// ErrDUTAgentUnsupported instead of a half-working non-exec push.
+func pushCompressedAgent(client *ssh.Client, b []byte) (agentPath, tmpfsDir string, err error) {
+	if _, err := runRemoteOutput(client, "mount -o remount,exec /tmp"); err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrDUTAgentUnsupported, err)
+	}
+
+	tempDir, err := runRemoteOutput(client, "mktemp --directory --tmpdir=/tmp dut-agent.XXXXXXXXXX")
+	if err != nil {
+		return "", "", err
+	}
+	tempDir = strings.TrimSpace(tempDir)
+	agentPath = path.Join(tempDir, "cache-agent")
+
+	if _, err := runRemoteOutput(client, "mount -t tmpfs -o rw,exec,mode=700 dut-agent "+tempDir); err != nil {
+		return "", "", err
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", "", err
+	}
+	defer session.Close()
+	session.Stdin = bytes.NewReader(b)
+	if _, err := session.Output("xz -d > " + agentPath); err != nil {
+		return "", "", err
+	}
+
+	if _, err := runRemoteOutput(client, "chmod +x "+agentPath); err != nil {
+		return "", "", err
+	}
+
+	return agentPath, tempDir, nil
+}
+
+// runRemoteOutput runs cmd on client in its own session and returns its
+// combined stdout.
+func runRemoteOutput(client *ssh.Client, cmd string) (string, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+	out, err := session.Output(cmd)
+	return string(out), err
+}
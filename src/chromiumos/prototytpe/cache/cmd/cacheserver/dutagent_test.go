@@ -0,0 +1,29 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestPrepareDUTAgentNotEmbedded makes sure PrepareDUTAgent fails with
+// ErrDUTAgentNotEmbedded for every arch until agentBinaryForArch is
+// actually populated, rather than silently proceeding with no agent bytes.
+func TestPrepareDUTAgentNotEmbedded(t *testing.T) {
+	m := NewDutAgentManager()
+	for _, arch := range []string{archAarch64, archX8664, "unknown-arch"} {
+		if _, err := m.PrepareDUTAgent("dut:22", arch); !errors.Is(err, ErrDUTAgentNotEmbedded) {
+			t.Errorf("PrepareDUTAgent(%q) error = %v, want ErrDUTAgentNotEmbedded", arch, err)
+		}
+	}
+}
+
+// TestDutAgentManagerCloseEmpty makes sure Close on a DutAgentManager with
+// no pushed agents is a no-op rather than panicking or blocking.
+func TestDutAgentManagerCloseEmpty(t *testing.T) {
+	m := NewDutAgentManager()
+	m.Close()
+}
@@ -6,6 +6,7 @@ package main
 
 import (
 	"chromiumos/test/util/portdiscovery"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -20,19 +21,24 @@ import (
 )
 
 const (
-	gsBucketParam       = "gs_bucket"
-	sourceURLKey        = "source_url"
-	downloadPrefix      = "/download/"
-	downloadLocalPrefix = "/download-local/"
-	staticPrefix        = "/static/"
-	isStagedPrefix      = "/is_staged/"
-	stagePrefix         = "/stage/"
-	checkHealthPrefix   = "/check_health/"
+	gsBucketParam         = "gs_bucket"
+	sourceURLKey          = "source_url"
+	downloadPrefix        = "/download/"
+	downloadLocalPrefix   = "/download-local/"
+	staticPrefix          = "/static/"
+	isStagedPrefix        = "/is_staged/"
+	stagePrefix           = "/stage/"
+	checkHealthPrefix     = "/check_health/"
+	prepareDUTAgentPrefix = "/prepare_dut_agent/"
+
+	dutAddrParam = "dut_addr"
+	archParam    = "arch"
 )
 
 // HTTPHandlers contains the cache server api endpoint logic
 type HTTPHandlers struct {
-	cache *Cache
+	cache     *Cache
+	dutAgents *DutAgentManager
 }
 
 // InstantiateHandlers creates the caching layer, sets up the HTTP handlers,
@@ -44,18 +50,23 @@ func InstantiateHandlers(port int, cacheLocation string) error {
 	}
 	defer cache.Close()
 
+	dutAgents := NewDutAgentManager()
+	defer dutAgents.Close()
+
 	// Clean up on SIGINT and SIGTERM
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, unix.SIGTERM)
 	go func() {
 		<-c
 		cache.Close()
+		dutAgents.Close()
 		os.Exit(1)
 	}()
 
 	// TODO(jaquesc): Add SSL (currently unnecessary for localhost)
 	h := HTTPHandlers{
-		cache: cache,
+		cache:     cache,
+		dutAgents: dutAgents,
 	}
 
 	http.HandleFunc(downloadPrefix, h.cacheGSHandler)
@@ -64,6 +75,7 @@ func InstantiateHandlers(port int, cacheLocation string) error {
 	http.HandleFunc(isStagedPrefix, h.isStagedHandler)
 	http.HandleFunc(stagePrefix, h.stageHandler)
 	http.HandleFunc(checkHealthPrefix, h.checkHealthHandler)
+	http.HandleFunc(prepareDUTAgentPrefix, h.prepareDUTAgentHandler)
 
 	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
@@ -82,7 +94,14 @@ func InstantiateHandlers(port int, cacheLocation string) error {
 	return nil
 }
 
-// cacheGSHandler handles the cache for GS
+// cacheGSHandler handles the cache for GS.
+//
+// Follow-up (b/cache-agent): this still always streams the artifact through
+// the cacheserver process. It is not yet routed through a prepared
+// DutAgentManager agent (see dutagent.go), so large artifact fetches don't
+// get the on-DUT decompression win PrepareDUTAgent is meant to enable -
+// prepareDUTAgentHandler is reachable but nothing calls it as part of a
+// fetch yet.
 func (h *HTTPHandlers) cacheGSHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -111,6 +130,33 @@ func (h *HTTPHandlers) stageHandler(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// prepareDUTAgentHandler pushes (or reuses) the cache-agent on the DUT
+// named by the dut_addr query param, built for the arch query param, and
+// writes back the path it was pushed to. If the DUT doesn't allow an
+// exec-mounted tmpfs, it reports ErrDUTAgentUnsupported so callers know to
+// keep streaming artifacts through the cacheserver process as before.
+func (h *HTTPHandlers) prepareDUTAgentHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("received %v request", prepareDUTAgentPrefix)
+	dutAddr := r.URL.Query().Get(dutAddrParam)
+	arch := r.URL.Query().Get(archParam)
+	if dutAddr == "" || arch == "" {
+		http.Error(w, fmt.Sprintf("URL must have %q and %q query parameters", dutAddrParam, archParam), http.StatusUnprocessableEntity)
+		return
+	}
+
+	agentPath, err := h.dutAgents.PrepareDUTAgent(dutAddr, arch)
+	if errors.Is(err, ErrDUTAgentUnsupported) || errors.Is(err, ErrDUTAgentNotEmbedded) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to prepare cache-agent on %s, %v", dutAddr, err), http.StatusBadRequest)
+		return
+	}
+
+	io.WriteString(w, agentPath)
+}
+
 // staticHandler handles GET requests to GS cache
 func (h *HTTPHandlers) staticHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -0,0 +1,47 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestPrepareDUTAgentHandlerMissingParams makes sure the handler rejects a
+// request missing dut_addr or arch instead of calling PrepareDUTAgent with
+// an empty value.
+func TestPrepareDUTAgentHandlerMissingParams(t *testing.T) {
+	h := HTTPHandlers{dutAgents: NewDutAgentManager()}
+
+	for name, url := range map[string]string{
+		"missing both":     "/prepare_dut_agent/",
+		"missing arch":     "/prepare_dut_agent/?dut_addr=dut:22",
+		"missing dut_addr": "/prepare_dut_agent/?arch=x86_64",
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			rec := httptest.NewRecorder()
+			h.prepareDUTAgentHandler(rec, req)
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+			}
+		})
+	}
+}
+
+// TestPrepareDUTAgentHandlerNotEmbedded makes sure the handler reports 501
+// Not Implemented for the not-yet-embedded cache-agent, rather than a
+// generic 400, so callers can tell "try later" apart from a bad request.
+func TestPrepareDUTAgentHandlerNotEmbedded(t *testing.T) {
+	h := HTTPHandlers{dutAgents: NewDutAgentManager()}
+
+	req := httptest.NewRequest(http.MethodGet, "/prepare_dut_agent/?dut_addr=dut:22&arch=x86_64", nil)
+	rec := httptest.NewRecorder()
+	h.prepareDUTAgentHandler(rec, req)
+	if rec.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotImplemented)
+	}
+}
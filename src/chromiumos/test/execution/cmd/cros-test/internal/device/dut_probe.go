@@ -0,0 +1,114 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package device
+
+import (
+	"regexp"
+	"strings"
+
+	"chromiumos/test/dut/cmd/cros-dut/dutssh"
+)
+
+// boardLineRegexp matches the CHROMEOS_RELEASE_BOARD line of /etc/lsb-release.
+// Signed images append a "-signed-<keyset>" suffix to the board name (e.g.
+// "eve-signed-mp-v4keys"), which callers expect stripped off.
+var boardLineRegexp = regexp.MustCompile(`CHROMEOS_RELEASE_BOARD=(\S+)`)
+var boardSignedSuffixRegexp = regexp.MustCompile(`-signed-.*$`)
+
+// operatorNameRegexp pulls an operator name out of a line of `modem status`
+// or `mmcli -m any --output-keyvalue` output, e.g. "operator-name: T-Mobile".
+var operatorNameRegexp = regexp.MustCompile(`(?i)operator[ _-]name\s*:\s*(\S.*\S)\s*$`)
+
+// ProbeLive connects to a running DUT via executor and fills in the subset
+// of DutInfo fields that FillDUTInfo would otherwise read from labapi, by
+// running on-device commands instead. This lets local runs and
+// lab-onboarding flows that don't have a lab config lookup available
+// produce the same label set via AppendChromeOsLabels.
+func ProbeLive(executor dutssh.CmdExecutor) (*DutInfo, error) {
+	info := &DutInfo{}
+
+	if out, err := runCmd(executor, "cat /etc/lsb-release"); err == nil {
+		if match := boardLineRegexp.FindStringSubmatch(out); match != nil {
+			info.Board = boardSignedSuffixRegexp.ReplaceAllString(match[1], "")
+		}
+	}
+
+	if model, err := runCmd(executor, "cros_config / name"); err == nil {
+		info.Model = strings.TrimSpace(model)
+	}
+
+	if hwid, err := runCmd(executor, "crossystem hwid"); err == nil {
+		info.HWID = strings.TrimSpace(hwid)
+	}
+
+	if sku, err := runCmd(executor, "mosys platform sku"); err == nil && strings.TrimSpace(sku) != "" {
+		info.Sku = strings.TrimSpace(sku)
+	} else if sku, err := runCmd(executor, "cros_config /identity sku-id"); err == nil {
+		info.Sku = strings.TrimSpace(sku)
+	}
+
+	if phase, err := runCmd(executor, "mosys platform version"); err == nil {
+		info.Phase = strings.ToUpper(strings.TrimSpace(phase))
+	}
+
+	if out, err := runCmd(executor, "modem status"); err == nil {
+		info.CarrierList = parseCarriers(out)
+	}
+
+	return info, nil
+}
+
+// runCmd runs cmd via executor and returns its stdout, treating a non-zero
+// return code the same way crosConfigIdentity does: as "no value", not an
+// error, since most of these probes are optional.
+func runCmd(executor dutssh.CmdExecutor, cmd string) (string, error) {
+	result, err := executor.RunCmd(cmd)
+	if err != nil {
+		return "", err
+	}
+	if result.ReturnCode != 0 {
+		return "", nil
+	}
+	return result.StdOut, nil
+}
+
+// parseCarriers extracts "carrier:<name>" labels from modem/mmcli output,
+// matching the label format AppendChromeOsLabels already produces for
+// chromeOS.Cellular.Operators.
+func parseCarriers(modemStatus string) []string {
+	var carriers []string
+	for _, line := range strings.Split(modemStatus, "\n") {
+		match := operatorNameRegexp.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		carriers = append(carriers, "carrier:"+strings.ToLower(match[1]))
+	}
+	return carriers
+}
+
+// MergeInto copies every non-zero-valued field of info into existing,
+// leaving fields existing already set untouched. It lets a live probe
+// backfill only the gaps left by a partial or missing lab config lookup.
+func (info *DutInfo) MergeInto(existing *DutInfo) {
+	if existing.Board == "" {
+		existing.Board = info.Board
+	}
+	if existing.Model == "" {
+		existing.Model = info.Model
+	}
+	if existing.HWID == "" {
+		existing.HWID = info.HWID
+	}
+	if existing.Sku == "" {
+		existing.Sku = info.Sku
+	}
+	if existing.Phase == "" {
+		existing.Phase = info.Phase
+	}
+	if len(existing.CarrierList) == 0 {
+		existing.CarrierList = info.CarrierList
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package device
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"chromiumos/test/dut/cmd/cros-dut/dutssh"
+)
+
+type fakeCmdExecutor struct {
+	cmdResults map[string]*dutssh.CmdResult
+}
+
+func (e fakeCmdExecutor) RunCmd(cmd string) (*dutssh.CmdResult, error) {
+	if result, ok := e.cmdResults[cmd]; ok {
+		return result, nil
+	}
+	return &dutssh.CmdResult{ReturnCode: 1}, nil
+}
+
+func cmdResult(stdout string, returnCode int32) *dutssh.CmdResult {
+	return &dutssh.CmdResult{StdOut: stdout, ReturnCode: returnCode}
+}
+
+func TestProbeLive(t *testing.T) {
+	executor := fakeCmdExecutor{
+		cmdResults: map[string]*dutssh.CmdResult{
+			"cat /etc/lsb-release":   cmdResult("CHROMEOS_RELEASE_BOARD=eve-signed-mp-v4keys\n", 0),
+			"cros_config / name":     cmdResult("Eve", 0),
+			"crossystem hwid":        cmdResult("EVE D2A-E2B-E6E", 0),
+			"mosys platform sku":     cmdResult("0", 0),
+			"mosys platform version": cmdResult("PVT", 0),
+			"modem status":           cmdResult("operator-name: T-Mobile\n", 0),
+		},
+	}
+
+	got, err := ProbeLive(executor)
+	if err != nil {
+		t.Fatalf("ProbeLive returned error: %v", err)
+	}
+	want := &DutInfo{
+		Board:       "eve",
+		Model:       "Eve",
+		HWID:        "EVE D2A-E2B-E6E",
+		Sku:         "0",
+		Phase:       "PVT",
+		CarrierList: []string{"carrier:t-mobile"},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("ProbeLive mismatch (-got +want):\n%s", diff)
+	}
+}
+
+func TestProbeLiveStripsSignedSuffix(t *testing.T) {
+	executor := fakeCmdExecutor{
+		cmdResults: map[string]*dutssh.CmdResult{
+			"cat /etc/lsb-release": cmdResult("CHROMEOS_RELEASE_BOARD=hatch-signed-mpkeys\n", 0),
+		},
+	}
+
+	got, err := ProbeLive(executor)
+	if err != nil {
+		t.Fatalf("ProbeLive returned error: %v", err)
+	}
+	if got.Board != "hatch" {
+		t.Errorf("Board = %q, want %q", got.Board, "hatch")
+	}
+}
+
+func TestMergeIntoOnlyFillsZeroValues(t *testing.T) {
+	probed := &DutInfo{Board: "eve", Model: "Eve", Sku: "0"}
+	existing := &DutInfo{Board: "already-set"}
+
+	probed.MergeInto(existing)
+
+	if existing.Board != "already-set" {
+		t.Errorf("Board should not be overwritten, got %q", existing.Board)
+	}
+	if existing.Model != "Eve" {
+		t.Errorf("Model = %q, want %q", existing.Model, "Eve")
+	}
+	if existing.Sku != "0" {
+		t.Errorf("Sku = %q, want %q", existing.Sku, "0")
+	}
+}
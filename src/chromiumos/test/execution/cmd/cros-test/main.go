@@ -19,6 +19,7 @@ import (
 
 	"chromiumos/test/execution/cmd/cros-test/internal/common"
 	"chromiumos/test/execution/errors"
+	"chromiumos/test/util/finder"
 	"chromiumos/test/util/metadata"
 	"chromiumos/test/util/portdiscovery"
 )
@@ -61,12 +62,23 @@ type args struct {
 	resultsDirPath  string
 	tlwAddr         string
 	metadataDirPath string
+	exclusionsPath  string
 	version         bool
 
 	// Server mode params
 	port int
 }
 
+// loadExclusions reads exclusionsPath, if set, into a list of
+// finder.Exclusion. An unset exclusionsPath is not an error: it just means
+// no exclusions were configured for this run.
+func loadExclusions(exclusionsPath string) ([]*finder.Exclusion, error) {
+	if exclusionsPath == "" {
+		return nil, nil
+	}
+	return finder.LoadExclusionsFile(exclusionsPath)
+}
+
 // runCLI is the entry point for running cros-test (executionservice) in CLI mode.
 func runCLI(ctx context.Context, d []string) int {
 	t := time.Now()
@@ -83,6 +95,7 @@ func runCLI(ctx context.Context, d []string) int {
 	fs.StringVar(&a.resultsDirPath, "resultdir", common.TestResultDir, "specify default directory for test harnesses to store their run result")
 	fs.StringVar(&a.tlwAddr, "tlwaddr", "", "specify the tlw address")
 	fs.StringVar(&a.metadataDirPath, "metadatadir", common.TestMetadataDir, "specify a directory that contain all test metadata proto files.")
+	fs.StringVar(&a.exclusionsPath, "exclusions", "", "specify a sidecar JSON file of finder.Exclusions to drop from the matched tests.")
 	fs.BoolVar(&a.version, "version", false, "print version and exit")
 	fs.Parse(d)
 
@@ -113,7 +126,13 @@ func runCLI(ctx context.Context, d []string) int {
 		return 2
 	}
 
-	rspn, err := runTests(ctx, logger, a.resultsDirPath, a.tlwAddr, metadata, req)
+	exclusions, err := loadExclusions(a.exclusionsPath)
+	if err != nil {
+		logger.Fatalln("Failed to load exclusions file: ", err)
+		return 2
+	}
+
+	rspn, err := runTests(ctx, logger, a.resultsDirPath, a.tlwAddr, metadata, req, exclusions)
 	if err != nil {
 		logger.Fatalln("Failed to run tests: ", err)
 		return 1
@@ -138,6 +157,7 @@ func startServer(d []string) int {
 	fs.StringVar(&a.resultsDirPath, "resultdir", common.TestResultDir, "specify the test execution request json input file")
 	fs.StringVar(&a.tlwAddr, "tlwaddr", "", "specify the tlw address")
 	fs.StringVar(&a.metadataDirPath, "metadatadir", common.TestMetadataDir, "specify a directory that contain all test metadata proto files.")
+	fs.StringVar(&a.exclusionsPath, "exclusions", "", "specify a sidecar JSON file of finder.Exclusions to drop from the matched tests.")
 	fs.IntVar(&a.port, "port", defaultPort, fmt.Sprintf("Specify the port for the server. Default value %d.", defaultPort))
 	fs.Parse(d)
 
@@ -169,7 +189,13 @@ func startServer(d []string) int {
 		return 2
 	}
 
-	server, closer := NewServer(logger, a.resultsDirPath, a.tlwAddr, metadata)
+	exclusions, err := loadExclusions(a.exclusionsPath)
+	if err != nil {
+		logger.Fatalln("Failed to load exclusions file: ", err)
+		return 2
+	}
+
+	server, closer := NewServer(logger, a.resultsDirPath, a.tlwAddr, metadata, exclusions)
 	defer closer()
 	err = server.Serve(l)
 	if err != nil {
@@ -53,12 +53,15 @@ func driverToTestsMapping(logger *log.Logger, mdList []*api.TestCaseMetadata) (m
 }
 
 // runTests runs the requested tests.
-func runTests(ctx context.Context, logger *log.Logger, resultRootDir, tlwAddr string, metadataList *api.TestCaseMetadataList, req *api.CrosTestRequest) (*api.CrosTestResponse, error) {
-	matchedMdList, err := finder.MatchedTestsForSuites(metadataList.Values, req.TestSuites)
+func runTests(ctx context.Context, logger *log.Logger, resultRootDir, tlwAddr string, metadataList *api.TestCaseMetadataList, req *api.CrosTestRequest, exclusions []*finder.Exclusion) (*api.CrosTestResponse, error) {
+	matchedMdList, exclusionHits, err := finder.MatchedTestsForSuites(metadataList.Values, req.TestSuites, exclusions)
 	if err != nil {
 		return nil, statuserrors.NewStatusError(statuserrors.InvalidArgument,
 			fmt.Errorf("failed to match test metadata: %v", err))
 	}
+	for _, hit := range exclusionHits {
+		logger.Printf("Excluding test %v (%v): %v", hit.TestID, hit.ExclusionType, hit.Reason)
+	}
 
 	driversToTests, err := driverToTestsMapping(logger, matchedMdList)
 	if err != nil {
@@ -16,6 +16,7 @@ import (
 
 	"chromiumos/lro"
 	"chromiumos/test/execution/cmd/cros-test/internal/common"
+	"chromiumos/test/util/finder"
 )
 
 // ExecutionServiceServer implementation of dut_service.proto
@@ -25,10 +26,11 @@ type ExecutionServiceServer struct {
 	resultRootDir string
 	tlwAddr       string
 	metadata      *api.TestCaseMetadataList
+	exclusions    []*finder.Exclusion
 }
 
 // NewServer creates an execution server.
-func NewServer(logger *log.Logger, resultRootDir, tlwAddr string, metadataList *api.TestCaseMetadataList) (*grpc.Server, func()) {
+func NewServer(logger *log.Logger, resultRootDir, tlwAddr string, metadataList *api.TestCaseMetadataList, exclusions []*finder.Exclusion) (*grpc.Server, func()) {
 	s := &ExecutionServiceServer{
 		manager: lro.New(),
 		logger:  logger,
@@ -36,6 +38,7 @@ func NewServer(logger *log.Logger, resultRootDir, tlwAddr string, metadataList *
 		resultRootDir: resultRootDir,
 		tlwAddr:       tlwAddr,
 		metadata:      metadataList,
+		exclusions:    exclusions,
 	}
 
 	server := grpc.NewServer()
@@ -62,7 +65,7 @@ func (s *ExecutionServiceServer) RunTests(ctx context.Context, req *api.CrosTest
 		return op, errors.Annotate(err, "RunTests: unable to determine results directory path").Err()
 	}
 
-	rspn, err := runTests(ctx, s.logger, resultsDir, s.tlwAddr, s.metadata, req)
+	rspn, err := runTests(ctx, s.logger, resultsDir, s.tlwAddr, s.metadata, req, s.exclusions)
 	if err != nil {
 		return op, errors.Annotate(err, "RunTests: failed to run test").Err()
 	}
@@ -6,6 +6,8 @@
 package coveragerules
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"io"
 	"sort"
@@ -15,6 +17,16 @@ import (
 	testpb "go.chromium.org/chromiumos/config/go/test/api"
 )
 
+// Format selects the output format Write uses.
+type Format string
+
+const (
+	// FormatText is the default tabwriter grid produced by WriteTextSummary.
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+)
+
 // WriteTextSummary writes a more easily human-readable summary of coverageRules
 // to w.
 //
@@ -60,3 +72,84 @@ func WriteTextSummary(w io.Writer, coverageRules []*testpb.CoverageRule) error {
 
 	return tabWriter.Flush()
 }
+
+// jsonDutCriterion is the JSON representation of a single DutCriterion
+// within a jsonCoverageRule, as written by WriteJSON.
+type jsonDutCriterion struct {
+	AttributeID string   `json:"attribute_id"`
+	Values      []string `json:"values"`
+}
+
+// jsonCoverageRule is the JSON representation of a single CoverageRule, as
+// written by WriteJSON.
+type jsonCoverageRule struct {
+	Name        string             `json:"name"`
+	DutCriteria []jsonDutCriterion `json:"dut_criteria"`
+}
+
+// WriteJSON writes coverageRules to w as a JSON array of
+// {name, dut_criteria: [{attribute_id, values}]} objects, in the same order
+// as coverageRules, with DutCriterion values sorted for a stable diff
+// between runs.
+func WriteJSON(w io.Writer, coverageRules []*testpb.CoverageRule) error {
+	rules := make([]jsonCoverageRule, 0, len(coverageRules))
+	for _, rule := range coverageRules {
+		criteria := make([]jsonDutCriterion, 0, len(rule.DutCriteria))
+		for _, criterion := range rule.DutCriteria {
+			values := append([]string(nil), criterion.Values...)
+			sort.Strings(values)
+			criteria = append(criteria, jsonDutCriterion{
+				AttributeID: criterion.AttributeId.Value,
+				Values:      values,
+			})
+		}
+		rules = append(rules, jsonCoverageRule{Name: rule.Name, DutCriteria: criteria})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rules)
+}
+
+// WriteCSV writes coverageRules to w as RFC-4180 CSV, one row per
+// DutCriterion, with columns name, attribute_id, attribute_values (values
+// joined with "|"). Fields are quoted by the csv package whenever needed, so
+// values containing "|", commas, or quotes survive a round trip.
+func WriteCSV(w io.Writer, coverageRules []*testpb.CoverageRule) error {
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write([]string{"name", "attribute_id", "attribute_values"}); err != nil {
+		return err
+	}
+
+	for _, rule := range coverageRules {
+		for _, criterion := range rule.DutCriteria {
+			values := append([]string(nil), criterion.Values...)
+			sort.Strings(values)
+
+			row := []string{rule.Name, criterion.AttributeId.Value, strings.Join(values, "|")}
+			if err := csvWriter.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// Write writes coverageRules to w in format, dispatching to WriteTextSummary,
+// WriteJSON, or WriteCSV. An empty format is treated as FormatText; any
+// other unknown format is an error.
+func Write(w io.Writer, format Format, coverageRules []*testpb.CoverageRule) error {
+	switch format {
+	case "", FormatText:
+		return WriteTextSummary(w, coverageRules)
+	case FormatJSON:
+		return WriteJSON(w, coverageRules)
+	case FormatCSV:
+		return WriteCSV(w, coverageRules)
+	default:
+		return fmt.Errorf("coveragerules.Write: unknown format %q", format)
+	}
+}
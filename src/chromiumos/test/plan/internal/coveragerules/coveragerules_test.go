@@ -61,3 +61,79 @@ rule2withalongname    attridB               attrv3
 		t.Errorf("coverageRules.WriteTextSummary returned %s, want %s", output.String(), expectedOutput)
 	}
 }
+
+func simpleCoverageRules() []*testpb.CoverageRule {
+	return []*testpb.CoverageRule{
+		{
+			Name: "rule1",
+			DutCriteria: []*testpb.DutCriterion{
+				{
+					AttributeId: &testpb.DutAttribute_Id{Value: "attridA"},
+					Values:      []string{"v2", "v1,withcomma"},
+				},
+			},
+		},
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var output bytes.Buffer
+
+	if err := coveragerules.WriteJSON(&output, simpleCoverageRules()); err != nil {
+		t.Fatalf("coveragerules.WriteJSON failed: %s", err)
+	}
+
+	expectedOutput := `[
+  {
+    "name": "rule1",
+    "dut_criteria": [
+      {
+        "attribute_id": "attridA",
+        "values": [
+          "v1,withcomma",
+          "v2"
+        ]
+      }
+    ]
+  }
+]
+`
+
+	if output.String() != expectedOutput {
+		t.Errorf("coveragerules.WriteJSON returned %s, want %s", output.String(), expectedOutput)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var output bytes.Buffer
+
+	if err := coveragerules.WriteCSV(&output, simpleCoverageRules()); err != nil {
+		t.Fatalf("coveragerules.WriteCSV failed: %s", err)
+	}
+
+	expectedOutput := "name,attribute_id,attribute_values\n" +
+		"rule1,attridA,\"v1,withcomma|v2\"\n"
+
+	if output.String() != expectedOutput {
+		t.Errorf("coveragerules.WriteCSV returned %q, want %q", output.String(), expectedOutput)
+	}
+}
+
+func TestWriteDispatchesByFormat(t *testing.T) {
+	coverageRules := simpleCoverageRules()
+
+	var textOutput, dispatchedOutput bytes.Buffer
+	if err := coveragerules.WriteTextSummary(&textOutput, coverageRules); err != nil {
+		t.Fatalf("coveragerules.WriteTextSummary failed: %s", err)
+	}
+	if err := coveragerules.Write(&dispatchedOutput, coveragerules.FormatText, coverageRules); err != nil {
+		t.Fatalf("coveragerules.Write failed: %s", err)
+	}
+	if textOutput.String() != dispatchedOutput.String() {
+		t.Errorf("coveragerules.Write(FormatText) returned %s, want %s", dispatchedOutput.String(), textOutput.String())
+	}
+
+	if err := coveragerules.Write(&bytes.Buffer{}, "invalid", coverageRules); err == nil {
+		t.Errorf("coveragerules.Write with an invalid format succeeded; want error")
+	}
+}
@@ -117,19 +117,14 @@ func (cc *CLICommand) Run() error {
 	ctx := context.Background()
 	fwService, err := firmwareservice.NewFirmwareService(ctx, dutAdapter, nil, cc.inputProto)
 	if err != nil {
-		if fwErr, ok := err.(*firmwareservice.FirmwareProvisionError); ok {
-			out.Status = fwErr.Status
-		} else {
-			log.Printf("expected FirmwareProvision to return error of type FirmwareProvisionError. got: %T", err)
-			out.Status = api.ProvisionFirmwareResponse_STATUS_UPDATE_FIRMWARE_FAILED
-		}
+		out.Status = firmwareservice.StatusOf(err)
 		return err
 	}
 
 	// Execute state machine
 	cs := state_machine.NewFirmwarePrepareState(fwService)
 	for cs != nil {
-		if err = cs.Execute(ctx); err != nil {
+		if out.Status, err = cs.Execute(ctx); err != nil {
 			break
 		}
 		cs = cs.Next()
@@ -139,12 +134,6 @@ func (cc *CLICommand) Run() error {
 		log.Println("Finished Successfuly!")
 		return nil
 	}
-	if fwErr, ok := err.(*firmwareservice.FirmwareProvisionError); ok {
-		out.Status = fwErr.Status
-	} else {
-		log.Printf("expected FirmwareProvision to return error of type FirmwareProvisionError. got: %T", err)
-		out.Status = api.ProvisionFirmwareResponse_STATUS_UPDATE_FIRMWARE_FAILED
-	}
 	return fmt.Errorf("failed to provision: %s", err)
 }
 
@@ -20,7 +20,10 @@ import (
 	api1 "go.chromium.org/chromiumos/config/go/test/lab/api"
 
 	"go.chromium.org/chromiumos/config/go/longrunning"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type FWProvisionServer struct {
@@ -123,9 +126,10 @@ func (ps *FWProvisionServer) Provision(ctx context.Context, req *api.ProvisionFi
 	}
 
 	// Execute state machine
+	var fwStatus api.ProvisionFirmwareResponse_Status
 	cs := state_machine.NewFirmwarePrepareState(fwService)
 	for cs != nil {
-		if err = cs.Execute(ctx); err != nil {
+		if fwStatus, err = cs.Execute(ctx); err != nil {
 			break
 		}
 		cs = cs.Next()
@@ -136,13 +140,50 @@ func (ps *FWProvisionServer) Provision(ctx context.Context, req *api.ProvisionFi
 		response.Status = api.ProvisionFirmwareResponse_STATUS_OK
 		ps.manager.SetResult(op.Name, &response)
 	} else {
-		response.Status = api.ProvisionFirmwareResponse_STATUS_UPDATE_FIRMWARE_FAILED
-		ps.manager.SetResult(op.Name, &response)
 		log.Println("Finished with error:", err)
+		ps.setOperationError(op, codeForStatus(fwStatus), fmt.Sprintf("fw-provision: %s", err), fwStatus.String())
 	}
 	return op, nil
 }
 
+// codeForStatus maps a ProvisionFirmwareResponse_Status to the grpc code
+// that best describes it, per the firmware_provision.proto doc comment on
+// ProvisionFirmwareResponse_Status ("details in Status message should be
+// parsed for ErrorInfo message with the following Reasons as the reason").
+func codeForStatus(s api.ProvisionFirmwareResponse_Status) codes.Code {
+	switch s {
+	case api.ProvisionFirmwareResponse_STATUS_INVALID_REQUEST:
+		return codes.InvalidArgument
+	case api.ProvisionFirmwareResponse_STATUS_DUT_UNREACHABLE_PRE_PROVISION,
+		api.ProvisionFirmwareResponse_STATUS_DUT_UNREACHABLE_POST_FIRMWARE_UPDATE:
+		return codes.Unavailable
+	case api.ProvisionFirmwareResponse_STATUS_FIRMWARE_MISMATCH_POST_FIRMWARE_UPDATE:
+		return codes.FailedPrecondition
+	case api.ProvisionFirmwareResponse_STATUS_UPDATE_FIRMWARE_FAILED:
+		return codes.Internal
+	default:
+		return codes.Aborted
+	}
+}
+
+// setOperationError is a simple helper to handle operation error
+// propagation, attaching reason (a ProvisionFirmwareResponse_Status) as an
+// ErrorInfo detail, per the proto's documented contract, so clients can
+// recover the specific failure reason without parsing msg.
+func (ps *FWProvisionServer) setOperationError(op *longrunning.Operation, code codes.Code, msg, reason string) {
+	st := status.New(code, msg)
+	st, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+	})
+	if err != nil {
+		log.Printf("Failed to attach status details: %v", err)
+		st = status.New(code, msg)
+	}
+	if err := ps.manager.SetError(op.Name, st); err != nil {
+		log.Printf("Failed to set Operation error, %s", err)
+	}
+}
+
 // validateProtoInputs ensures the proto part of the CLI input is valid
 func (cc *FWProvisionServer) validateProtoInputs(req *api.ProvisionFirmwareRequest) error {
 	if len(req.Board) == 0 {
@@ -17,21 +17,31 @@ const FirmwarePathTmp = "/tmp/fw-provisioning-service/"
 const CurlWithRetriesArgsFW = "-S -s -v -# -C - --retry 3 --retry-delay 60"
 
 // ImageArchiveMetadata will be the value of the map in which the key is the
-// gsPath, so we can avoid downloading/reprocessing same archives.
+// canonicalized gsPath, so we can avoid downloading/reprocessing same archives.
 type ImageArchiveMetadata struct {
 	ArchivePath string
 	ArchiveDir  string
 	ListOfFiles map[string]struct{}
+	// Sha256 is the digest of the downloaded archive, so a caller that knows
+	// what digest it expects (none do yet - see downloadAndProcessArchive)
+	// can be sure DownloadAndProcess served the content it asked for.
+	Sha256 string
 }
 
-func MakeImageArchiveMetadata(archivePath string, archiveDir string, listOfFiles []string) *ImageArchiveMetadata {
-	m := &ImageArchiveMetadata{ArchivePath: archivePath, ArchiveDir: archiveDir, ListOfFiles: make(map[string]struct{})}
+func MakeImageArchiveMetadata(archivePath string, archiveDir string, listOfFiles []string, sha256 string) *ImageArchiveMetadata {
+	m := &ImageArchiveMetadata{ArchivePath: archivePath, ArchiveDir: archiveDir, ListOfFiles: make(map[string]struct{}), Sha256: sha256}
 	for _, f := range listOfFiles {
 		m.ListOfFiles[f] = struct{}{}
 	}
 	return m
 }
 
+// canonicalizeGsPath normalizes a gs:// URL so that paths that only differ
+// by incidental whitespace or a trailing slash dedupe to the same cache key.
+func canonicalizeGsPath(gsPath string) string {
+	return strings.TrimRight(strings.TrimSpace(gsPath), "/")
+}
+
 func (m *ImageArchiveMetadata) IncludesFile(filename string) bool {
 	_, isPresent := m.ListOfFiles[filename]
 	return isPresent
@@ -50,9 +60,17 @@ func extractFileFromImage(ctx context.Context, fileInArchive string, imageMetada
 }
 
 // downloadAndProcessArchive downloads image from gsPath onto whatever device
-// is connected to |s|.
+// is connected to |s|. If expectedSha256 is non-empty, the downloaded
+// archive's digest is checked against it and an error is returned on
+// mismatch instead of extracting content that isn't what the caller asked
+// for. None of DownloadAndProcess's current callers have an expected digest
+// to pass (ProvisionFirmwareRequest's StoragePaths carry no digest field),
+// so expectedSha256 is "" in practice today; the computed digest is still
+// recorded on the returned ImageArchiveMetadata.
 // Returns ImageArchiveMetadata with metadata about the archive.
-func downloadAndProcessArchive(ctx context.Context, s common_utils.ServiceAdapterInterface, gsPath string) (*ImageArchiveMetadata, error) {
+func downloadAndProcessArchive(ctx context.Context, s common_utils.ServiceAdapterInterface, gsPath, expectedSha256 string) (*ImageArchiveMetadata, error) {
+	gsPath = canonicalizeGsPath(gsPath)
+
 	// Infer names for the local files and folders from basename of gsPath.
 	archiveFilename := filepath.Base(gsPath)
 
@@ -87,13 +105,23 @@ func downloadAndProcessArchive(ctx context.Context, s common_utils.ServiceAdapte
 		return nil, fmt.Errorf("remote CopyData() failed: %w", err)
 	}
 
+	actualSha256, err := sha256OfRemoteFile(ctx, archivePath, s)
+	if err != nil {
+		s.DeleteDirectory(ctx, archiveDir)
+		return nil, fmt.Errorf("failed to compute archive digest: %w", err)
+	}
+	if expectedSha256 != "" && actualSha256 != expectedSha256 {
+		s.DeleteDirectory(ctx, archiveDir)
+		return nil, fmt.Errorf("archive %v has sha256 %v, want %v", gsPath, actualSha256, expectedSha256)
+	}
+
 	listOfFiles, err := listFilesInArchive(ctx, archivePath, s)
 	if err != nil {
 		s.DeleteDirectory(ctx, archiveDir)
 		return nil, fmt.Errorf("failed to list archive contents: %w", err)
 	}
 
-	metadata := MakeImageArchiveMetadata(archivePath, archiveDir, listOfFiles)
+	metadata := MakeImageArchiveMetadata(archivePath, archiveDir, listOfFiles, actualSha256)
 	return metadata, nil
 }
 
@@ -136,6 +164,20 @@ func listFilesInArchive(ctx context.Context, archivePath string, s common_utils.
 	return strings.Split(out, "\n"), nil
 }
 
+// sha256OfRemoteFile returns the sha256 digest of path on whatever device is
+// connected to |s|, as a lowercase hex string.
+func sha256OfRemoteFile(ctx context.Context, path string, s common_utils.ServiceAdapterInterface) (string, error) {
+	out, err := s.RunCmd(ctx, "sha256sum", []string{path})
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("unexpected sha256sum output: %q", out)
+	}
+	return fields[0], nil
+}
+
 // PickAndExtractMainImage uses provided list of |filesInArchive| to pick a main
 // image to use, extracts only it, and returns a path to extracted image.
 // board and model(aka variant) are optional.
@@ -4,6 +4,7 @@
 package firmwareservice
 
 import (
+	"errors"
 	"fmt"
 
 	"go.chromium.org/chromiumos/config/go/test/api"
@@ -11,45 +12,68 @@ import (
 
 type any interface{}
 
-type FirmwareProvisionError struct {
+// StatusError pairs a ProvisionFirmwareResponse_Status reason code with the
+// error that produced it, so callers can recover the reason code without
+// collapsing everything into a generic failure.
+type StatusError struct {
 	Status api.ProvisionFirmwareResponse_Status
 	Err    error
 }
 
-func (fe *FirmwareProvisionError) Error() string {
+func (fe *StatusError) Error() string {
 	return fmt.Sprintf("%v: %v", fe.Status.String(), fe.Err)
 }
 
-func InvalidRequestErr(format string, a ...any) *FirmwareProvisionError {
-	return &FirmwareProvisionError{
+func (fe *StatusError) Unwrap() error {
+	return fe.Err
+}
+
+// StatusOf returns the ProvisionFirmwareResponse_Status carried by err if err
+// is (or wraps) a *StatusError, and STATUS_UPDATE_FIRMWARE_FAILED for any
+// other non-nil error. It lets the state machine and the server bubble up
+// the most specific reason code a lower layer already determined, instead of
+// re-wrapping every failure as a generic update failure.
+func StatusOf(err error) api.ProvisionFirmwareResponse_Status {
+	if err == nil {
+		return api.ProvisionFirmwareResponse_STATUS_OK
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Status
+	}
+	return api.ProvisionFirmwareResponse_STATUS_UPDATE_FIRMWARE_FAILED
+}
+
+func InvalidRequestErr(format string, a ...any) *StatusError {
+	return &StatusError{
 		Status: api.ProvisionFirmwareResponse_STATUS_INVALID_REQUEST,
 		Err:    fmt.Errorf(format, a),
 	}
 }
 
-func UnreachablePreProvisionErr(format string, a ...any) *FirmwareProvisionError {
-	return &FirmwareProvisionError{
+func UnreachablePreProvisionErr(format string, a ...any) *StatusError {
+	return &StatusError{
 		Status: api.ProvisionFirmwareResponse_STATUS_DUT_UNREACHABLE_PRE_PROVISION,
 		Err:    fmt.Errorf(format, a),
 	}
 }
 
-func UpdateFirmwareFailedErr(format string, a ...any) *FirmwareProvisionError {
-	return &FirmwareProvisionError{
+func UpdateFirmwareFailedErr(format string, a ...any) *StatusError {
+	return &StatusError{
 		Status: api.ProvisionFirmwareResponse_STATUS_UPDATE_FIRMWARE_FAILED,
 		Err:    fmt.Errorf(format, a),
 	}
 }
 
-func FirmwareMismatchPostProvisionErr(format string, a ...any) *FirmwareProvisionError {
-	return &FirmwareProvisionError{
+func FirmwareMismatchPostProvisionErr(format string, a ...any) *StatusError {
+	return &StatusError{
 		Status: api.ProvisionFirmwareResponse_STATUS_FIRMWARE_MISMATCH_POST_FIRMWARE_UPDATE,
 		Err:    fmt.Errorf(format, a),
 	}
 }
 
-func UnreachablePostProvisionErr(format string, a ...any) *FirmwareProvisionError {
-	return &FirmwareProvisionError{
+func UnreachablePostProvisionErr(format string, a ...any) *StatusError {
+	return &StatusError{
 		Status: api.ProvisionFirmwareResponse_STATUS_DUT_UNREACHABLE_POST_FIRMWARE_UPDATE,
 		Err:    fmt.Errorf(format, a),
 	}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"chromiumos/test/provision/lib/servo_lib"
@@ -20,6 +21,45 @@ import (
 	"go.chromium.org/chromiumos/config/go/test/api"
 )
 
+// DefaultDownloadConcurrency is the default number of images
+// DownloadAndProcess will fetch and extract in parallel.
+const DefaultDownloadConcurrency = 4
+
+// DownloadProgress receives Started/Finished notifications as
+// DownloadAndProcess works through images, analogous to the progress.Writer
+// used by fflash's PushCompressedExecutable to report push progress.
+type DownloadProgress interface {
+	// Started is called once, right before gsPath starts downloading.
+	Started(gsPath string)
+	// Finished is called once per gsPath, after the download and extraction
+	// either succeeded (err == nil) or failed.
+	Finished(gsPath string, err error)
+}
+
+// logDownloadProgress is the default DownloadProgress: it just logs.
+type logDownloadProgress struct{}
+
+func (logDownloadProgress) Started(gsPath string) {
+	log.Printf("[FW Provisioning: Prepare FW] downloading %v\n", gsPath)
+}
+
+func (logDownloadProgress) Finished(gsPath string, err error) {
+	if err != nil {
+		log.Printf("[FW Provisioning: Prepare FW] failed to download and process %v: %v\n", gsPath, err)
+		return
+	}
+	log.Printf("[FW Provisioning: Prepare FW] finished downloading and processing %v\n", gsPath)
+}
+
+// downloadOnce guards a single canonicalized gsPath so that concurrent
+// DownloadAndProcess calls for the same archive download and extract it
+// exactly once, and every caller observes the same result.
+type downloadOnce struct {
+	once     sync.Once
+	metadata ImageArchiveMetadata
+	err      error
+}
+
 // FirmwareService implements ServiceInterface
 type FirmwareService struct {
 	// In case of flashing over SSH, |connection| connects to the DUT.
@@ -42,10 +82,24 @@ type FirmwareService struct {
 
 	ecChip string
 
-	// imagesMetadata is a map from gspath -> ImageArchiveMetadata.
+	// imagesMetadata is a map from canonicalized gspath -> ImageArchiveMetadata.
 	// Allows to avoid redownloading/reprocessing archives.
 	imagesMetadata map[string]ImageArchiveMetadata
 
+	// downloadsMu guards imagesMetadata and downloads below, which are
+	// written concurrently by DownloadAndProcess.
+	downloadsMu sync.Mutex
+	// downloads is a map from canonicalized gspath -> downloadOnce, used to
+	// make sure a given archive is only downloaded/extracted once even if
+	// multiple roles (MainRw/MainRo/EcRo/PdRo) request it concurrently.
+	downloads map[string]*downloadOnce
+
+	// downloadConcurrency caps how many images FirmwarePrepareState will
+	// download and extract in parallel.
+	downloadConcurrency int
+	// progress reports per-image download/extraction progress.
+	progress DownloadProgress
+
 	useServo bool
 	// servoConfig provides dut-controls and programmer argument for flashing
 	servoConfig *servo_lib.ServoConfig
@@ -78,12 +132,14 @@ func NewFirmwareService(ctx context.Context, dutAdapter common_utils.ServiceAdap
 	useServo := req.GetUseServo()
 
 	fws := FirmwareService{
-		connection:       dutAdapter,
-		board:            board,
-		model:            model,
-		force:            force,
-		useServo:         useServo,
-		useSimpleRequest: useSimpleRequest,
+		connection:          dutAdapter,
+		board:               board,
+		model:               model,
+		force:               force,
+		useServo:            useServo,
+		useSimpleRequest:    useSimpleRequest,
+		downloadConcurrency: DefaultDownloadConcurrency,
+		progress:            logDownloadProgress{},
 	}
 
 	if useSimpleRequest {
@@ -106,6 +162,7 @@ func NewFirmwareService(ctx context.Context, dutAdapter common_utils.ServiceAdap
 		fws.pdRoPath = detailedRequest.PdRoPayload.GetFirmwareImagePath()
 
 		fws.imagesMetadata = make(map[string]ImageArchiveMetadata)
+		fws.downloads = make(map[string]*downloadOnce)
 	}
 
 	if useServo {
@@ -343,12 +400,18 @@ func (fws *FirmwareService) DeleteArchiveDirectories() error {
 //
 // If flashing over ssh, simply calls runFutility().
 // If flashing over servo, also runs pre- and post-flashing dut-controls.
+// The returned error, if any, is a *StatusError.
 func (fws FirmwareService) FlashWithFutility(ctx context.Context, rwOnly bool, futilityImageArgs []string) error {
+	var err error
 	if fws.useServo {
-		return fws.servoFlash(ctx, rwOnly, futilityImageArgs)
+		err = fws.servoFlash(ctx, rwOnly, futilityImageArgs)
 	} else {
-		return fws.sshFlash(ctx, rwOnly, futilityImageArgs)
+		err = fws.sshFlash(ctx, rwOnly, futilityImageArgs)
+	}
+	if err != nil {
+		return UpdateFirmwareFailedErr(err.Error())
 	}
+	return nil
 }
 
 func (fws FirmwareService) sshFlash(ctx context.Context, rwOnly bool, futilityImageArgs []string) error {
@@ -416,6 +479,22 @@ func (fws FirmwareService) GetConnectionToFlashingDevice() common_utils.ServiceA
 	}
 }
 
+// GetDownloadConcurrency returns how many images DownloadAndProcess may fetch
+// and extract in parallel.
+func (fws FirmwareService) GetDownloadConcurrency() int {
+	return fws.downloadConcurrency
+}
+
+// SetDownloadConcurrency overrides the default download concurrency cap.
+func (fws *FirmwareService) SetDownloadConcurrency(n int) {
+	fws.downloadConcurrency = n
+}
+
+// SetDownloadProgress overrides the default DownloadProgress reporter.
+func (fws *FirmwareService) SetDownloadProgress(p DownloadProgress) {
+	fws.progress = p
+}
+
 func (fws FirmwareService) IsServoUsed() bool {
 	return fws.useServo
 }
@@ -436,27 +515,57 @@ func (fws FirmwareService) GetPdRoPath() string {
 	return fws.pdRoPath.GetPath()
 }
 
-// DownloadAndProcess downloads and extracts a provided archive,
-// and stores the folder with contents in s.service.archiveFolders map.
-func (fws FirmwareService) DownloadAndProcess(ctx context.Context, gspath string) error {
-	connection := fws.GetConnectionToFlashingDevice()
-	if _, alreadyDownloaded := fws.imagesMetadata[gspath]; !alreadyDownloaded {
-		archiveMetadata, err := downloadAndProcessArchive(ctx, connection, gspath)
+// DownloadAndProcess downloads and extracts a provided archive, and stores
+// the folder with contents in fws.imagesMetadata, keyed by the archive's
+// canonicalized gsPath. If another DownloadAndProcess call for the same
+// archive is already running or has already completed, this call waits for
+// and reuses that result instead of downloading/extracting again, so it is
+// safe to call concurrently (e.g. fanned out over an errgroup.Group) with
+// gsPaths that alias the same archive. If expectedSha256 is non-empty, the
+// downloaded archive's digest is verified against it and a mismatch is
+// reported as an error instead of being silently extracted; pass "" if the
+// caller has no expected digest to check against. This check is made
+// against every caller's own expectedSha256, even one that lost the race to
+// actually perform the download, so a caller that supplies a digest is
+// never skipped just because a concurrent, less strict caller happened to
+// download the archive first. The returned error, if any, is a
+// *StatusError.
+func (fws *FirmwareService) DownloadAndProcess(ctx context.Context, gspath, expectedSha256 string) error {
+	key := canonicalizeGsPath(gspath)
+
+	fws.downloadsMu.Lock()
+	dl, inFlightOrDone := fws.downloads[key]
+	if !inFlightOrDone {
+		dl = &downloadOnce{}
+		fws.downloads[key] = dl
+	}
+	fws.downloadsMu.Unlock()
+
+	dl.once.Do(func() {
+		fws.progress.Started(gspath)
+		connection := fws.GetConnectionToFlashingDevice()
+		archiveMetadata, err := downloadAndProcessArchive(ctx, connection, key, expectedSha256)
 		if err != nil {
-			log.Printf("[FW Provisioning: Prepare FW] failed to download and process %v: %v\n", gspath, err)
-			return err
+			dl.err = UpdateFirmwareFailedErr(err.Error())
 		} else {
-			log.Printf("[FW Provisioning: Prepare FW] downloaded %v to %v. Files in archive: %v\n",
-				gspath, archiveMetadata.ArchivePath, len(archiveMetadata.ListOfFiles))
+			dl.metadata = *archiveMetadata
+			fws.downloadsMu.Lock()
+			fws.imagesMetadata[key] = *archiveMetadata
+			fws.downloadsMu.Unlock()
 		}
-		fws.imagesMetadata[gspath] = *archiveMetadata
+		fws.progress.Finished(gspath, err)
+	})
+	if dl.err == nil && expectedSha256 != "" && dl.metadata.Sha256 != expectedSha256 {
+		return UpdateFirmwareFailedErr("archive %v has sha256 %v, want %v", gspath, dl.metadata.Sha256, expectedSha256)
 	}
-	return nil
+	return dl.err
 }
 
 // GetImageMetadata returns (ImageArchiveMetadata, IsImageMetadataPresent)
-func (fws FirmwareService) GetImageMetadata(gspath string) (ImageArchiveMetadata, bool) {
-	metadata, ok := fws.imagesMetadata[gspath]
+func (fws *FirmwareService) GetImageMetadata(gspath string) (ImageArchiveMetadata, bool) {
+	fws.downloadsMu.Lock()
+	defer fws.downloadsMu.Unlock()
+	metadata, ok := fws.imagesMetadata[canonicalizeGsPath(gspath)]
 	return metadata, ok
 }
 
@@ -0,0 +1,153 @@
+// Copyright 2022 The ChromiumOS Authors.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package firmwareservice
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/smartystreets/goconvey/convey"
+
+	mock_common_utils "chromiumos/test/provision/v2/mock-common-utils"
+)
+
+// newTestFirmwareService builds a FirmwareService with just enough state for
+// DownloadAndProcess, bypassing NewFirmwareService's request validation.
+func newTestFirmwareService(conn *mock_common_utils.MockServiceAdapterInterface) *FirmwareService {
+	return &FirmwareService{
+		connection:     conn,
+		imagesMetadata: make(map[string]ImageArchiveMetadata),
+		downloads:      make(map[string]*downloadOnce),
+		progress:       logDownloadProgress{},
+	}
+}
+
+// TestDownloadAndProcessDedupesConcurrentCallers makes sure two concurrent
+// DownloadAndProcess calls for the same gsPath only download and extract the
+// archive once, and both callers observe the same resulting metadata.
+func TestDownloadAndProcessDedupesConcurrentCallers(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	Convey("DownloadAndProcess dedupes concurrent callers of the same gsPath", t, func() {
+		conn := mock_common_utils.NewMockServiceAdapterInterface(ctrl)
+		conn.EXPECT().RunCmd(gomock.Any(), "mktemp", gomock.Any()).Return("/tmp/archivedir\n", nil).Times(1)
+		conn.EXPECT().CopyData(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		conn.EXPECT().RunCmd(gomock.Any(), "sha256sum", gomock.Any()).Return("abc123  /tmp/archivedir/image.tar.bz2\n", nil).Times(1)
+		conn.EXPECT().RunCmd(gomock.Any(), "tar", gomock.Any()).Return("image.bin\n", nil).Times(1)
+
+		fws := newTestFirmwareService(conn)
+
+		const gsPath = "gs://bucket/board/image.tar.bz2"
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = fws.DownloadAndProcess(context.Background(), gsPath, "")
+			}(i)
+		}
+		wg.Wait()
+
+		So(errs[0], ShouldBeNil)
+		So(errs[1], ShouldBeNil)
+		metadata, ok := fws.GetImageMetadata(gsPath)
+		So(ok, ShouldBeTrue)
+		So(metadata.ArchivePath, ShouldEqual, "/tmp/archivedir/image.tar.bz2")
+		So(metadata.Sha256, ShouldEqual, "abc123")
+	})
+}
+
+// TestDownloadAndProcessCachesErrorAcrossConcurrentCallers makes sure that
+// when the download fails, every concurrent caller for that gsPath observes
+// the same cached error instead of retrying the download.
+func TestDownloadAndProcessCachesErrorAcrossConcurrentCallers(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	Convey("DownloadAndProcess caches a download failure for concurrent callers", t, func() {
+		conn := mock_common_utils.NewMockServiceAdapterInterface(ctrl)
+		conn.EXPECT().RunCmd(gomock.Any(), "mktemp", gomock.Any()).Return("/tmp/archivedir\n", nil).Times(1)
+		conn.EXPECT().CopyData(gomock.Any(), gomock.Any(), gomock.Any()).Return(context.DeadlineExceeded).Times(1)
+		conn.EXPECT().DeleteDirectory(gomock.Any(), gomock.Any()).Times(1)
+
+		fws := newTestFirmwareService(conn)
+
+		const gsPath = "gs://bucket/board/image.tar.bz2"
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = fws.DownloadAndProcess(context.Background(), gsPath, "")
+			}(i)
+		}
+		wg.Wait()
+
+		So(errs[0], ShouldNotBeNil)
+		So(errs[1], ShouldNotBeNil)
+		So(errs[0], ShouldEqual, errs[1])
+		_, ok := fws.GetImageMetadata(gsPath)
+		So(ok, ShouldBeFalse)
+	})
+}
+
+// TestDownloadAndProcessVerifiesExpectedSha256 makes sure a digest mismatch
+// is reported as an error instead of the mismatched content being silently
+// extracted and cached.
+func TestDownloadAndProcessVerifiesExpectedSha256(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	Convey("DownloadAndProcess rejects an archive whose digest doesn't match expectedSha256", t, func() {
+		conn := mock_common_utils.NewMockServiceAdapterInterface(ctrl)
+		conn.EXPECT().RunCmd(gomock.Any(), "mktemp", gomock.Any()).Return("/tmp/archivedir\n", nil).Times(1)
+		conn.EXPECT().CopyData(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		conn.EXPECT().RunCmd(gomock.Any(), "sha256sum", gomock.Any()).Return("abc123  /tmp/archivedir/image.tar.bz2\n", nil).Times(1)
+		conn.EXPECT().DeleteDirectory(gomock.Any(), gomock.Any()).Times(1)
+
+		fws := newTestFirmwareService(conn)
+
+		const gsPath = "gs://bucket/board/image.tar.bz2"
+		err := fws.DownloadAndProcess(context.Background(), gsPath, "def456")
+
+		So(err, ShouldNotBeNil)
+		_, ok := fws.GetImageMetadata(gsPath)
+		So(ok, ShouldBeFalse)
+	})
+}
+
+// TestDownloadAndProcessVerifiesExpectedSha256OfCachedResult makes sure a
+// caller supplying expectedSha256 still gets it checked even when a prior
+// caller already downloaded and cached the archive without checking a
+// digest, i.e. the check isn't skipped just because this caller didn't win
+// the dl.once.Do race.
+func TestDownloadAndProcessVerifiesExpectedSha256OfCachedResult(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	Convey("DownloadAndProcess checks expectedSha256 against an already-cached result", t, func() {
+		conn := mock_common_utils.NewMockServiceAdapterInterface(ctrl)
+		conn.EXPECT().RunCmd(gomock.Any(), "mktemp", gomock.Any()).Return("/tmp/archivedir\n", nil).Times(1)
+		conn.EXPECT().CopyData(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+		conn.EXPECT().RunCmd(gomock.Any(), "sha256sum", gomock.Any()).Return("abc123  /tmp/archivedir/image.tar.bz2\n", nil).Times(1)
+		conn.EXPECT().RunCmd(gomock.Any(), "tar", gomock.Any()).Return("image.bin\n", nil).Times(1)
+
+		fws := newTestFirmwareService(conn)
+		const gsPath = "gs://bucket/board/image.tar.bz2"
+
+		// First caller has no expected digest and populates the cache.
+		So(fws.DownloadAndProcess(context.Background(), gsPath, ""), ShouldBeNil)
+
+		// A later caller for the same gsPath asks for a digest the cached
+		// result doesn't have, and must not be served it unchecked.
+		err := fws.DownloadAndProcess(context.Background(), gsPath, "wrongdigest")
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -7,10 +7,10 @@
 package state_machine
 
 import (
-	common_utils "chromiumos/test/provision/v2/common-utils"
 	firmwareservice "chromiumos/test/provision/v2/fw-provision/service"
 	"context"
-	"log"
+
+	"go.chromium.org/chromiumos/config/go/test/api"
 )
 
 // FirmwarePostInstallState cleans up temporary folders and reboots the DUT.
@@ -19,19 +19,19 @@ type FirmwarePostInstallState struct {
 }
 
 // Execute deletes all folders with firmware image archives.
-func (s FirmwarePostInstallState) Execute(ctx context.Context, log *log.Logger) error {
+func (s FirmwarePostInstallState) Execute(ctx context.Context) (api.ProvisionFirmwareResponse_Status, error) {
 	s.service.DeleteArchiveDirectories()
-	err := s.service.RestartDut(ctx, false)
-	if err != nil {
-		return firmwareservice.UnreachablePostProvisionErr(err.Error())
+	if err := s.service.RestartDut(ctx, false); err != nil {
+		err = firmwareservice.UnreachablePostProvisionErr(err.Error())
+		return firmwareservice.StatusOf(err), err
 	}
 
 	// TODO(sfrolov): if Firmware Version Mismatched:
-	// return FirmwareMismatchPostProvisionErr("expected fw version: %v, got: %v")
-	return nil
+	// return firmwareservice.StatusOf(err), FirmwareMismatchPostProvisionErr("expected fw version: %v, got: %v")
+	return api.ProvisionFirmwareResponse_STATUS_OK, nil
 }
 
-func (s FirmwarePostInstallState) Next() common_utils.ServiceState {
+func (s FirmwarePostInstallState) Next() FirmwareState {
 	return nil
 }
 
@@ -5,67 +5,92 @@
 package state_machine
 
 import (
-	common_utils "chromiumos/test/provision/v2/common-utils"
 	firmwareservice "chromiumos/test/provision/v2/fw-provision/service"
 	"context"
 	"log"
+
+	"go.chromium.org/chromiumos/config/go/test/api"
+	"golang.org/x/sync/errgroup"
 )
 
+// FirmwareState is a single state in the fw-provision state machine. Unlike
+// common_utils.ServiceState, Execute reports the specific
+// ProvisionFirmwareResponse_Status a failure should be surfaced as, instead
+// of forcing every error into a single generic status.
+type FirmwareState interface {
+	// Execute runs the state.
+	Execute(ctx context.Context) (api.ProvisionFirmwareResponse_Status, error)
+	// Next gets the next state in the state machine.
+	Next() FirmwareState
+	// Name gets the fully qualified name of this state.
+	Name() string
+}
+
 type FirmwarePrepareState struct {
 	service *firmwareservice.FirmwareService
 }
 
-func NewFirmwarePrepareState(service *firmwareservice.FirmwareService) common_utils.ServiceState {
+func NewFirmwarePrepareState(service *firmwareservice.FirmwareService) FirmwareState {
 	return FirmwarePrepareState{
 		service: service,
 	}
 }
 
 // FirmwarePrepareState downloads and extracts every image from the request.
-// The already downloaded images will not be downloaded and extracted again.
-func (s FirmwarePrepareState) Execute(ctx context.Context) error {
+// The images are fanned out over an errgroup.Group, bounded by the service's
+// download concurrency cap, and each distinct archive (by canonicalized
+// gs:// URL) is only downloaded and extracted once, even if two roles (e.g.
+// MainRo and EcRo) point at the same archive. The first failure cancels the
+// rest of the group.
+func (s FirmwarePrepareState) Execute(ctx context.Context) (api.ProvisionFirmwareResponse_Status, error) {
 	firmwareImageDestination := "DUT"
 	if s.service.IsServoUsed() {
 		firmwareImageDestination = "ServoHost"
 	}
 	log.Printf("[FW Provisioning: Prepare FW] downloading Firmware Images onto %v\n", firmwareImageDestination)
 
+	var imagePaths []string
 	if s.service.GetUseSimpleRequest() {
 		imagePath, _ := s.service.GetSimpleRequest()
-		if len(imagePath) > 0 {
-			if err := s.service.DownloadAndProcess(ctx, imagePath); err != nil {
-				return firmwareservice.UpdateFirmwareFailedErr(err.Error())
-			}
-		} else {
+		if len(imagePath) == 0 {
 			// was checked for earlier
 			panic("SimpleRequest has empty url")
 		}
+		imagePaths = append(imagePaths, imagePath)
 	} else {
-		if mainRw := s.service.GetMainRwPath(); len(mainRw) > 0 {
-			if err := s.service.DownloadAndProcess(ctx, mainRw); err != nil {
-				return firmwareservice.UpdateFirmwareFailedErr(err.Error())
-			}
-		}
-		if mainRo := s.service.GetMainRoPath(); len(mainRo) > 0 {
-			if err := s.service.DownloadAndProcess(ctx, mainRo); err != nil {
-				return firmwareservice.UpdateFirmwareFailedErr(err.Error())
-			}
-		}
-		if ecRoPath := s.service.GetEcRoPath(); len(ecRoPath) > 0 {
-			if err := s.service.DownloadAndProcess(ctx, ecRoPath); err != nil {
-				return firmwareservice.UpdateFirmwareFailedErr(err.Error())
-			}
-		}
-		if pdRoPath := s.service.GetPdRoPath(); len(pdRoPath) > 0 {
-			if err := s.service.DownloadAndProcess(ctx, pdRoPath); err != nil {
-				return firmwareservice.UpdateFirmwareFailedErr(err.Error())
+		for _, imagePath := range []string{
+			s.service.GetMainRwPath(),
+			s.service.GetMainRoPath(),
+			s.service.GetEcRoPath(),
+			s.service.GetPdRoPath(),
+		} {
+			if len(imagePath) > 0 {
+				imagePaths = append(imagePaths, imagePath)
 			}
 		}
 	}
-	return nil
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, s.service.GetDownloadConcurrency())
+	for _, imagePath := range imagePaths {
+		imagePath := imagePath
+		eg.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			// No caller has an expected digest to verify against yet:
+			// ProvisionFirmwareRequest's StoragePaths carry no sha256
+			// field. DownloadAndProcess still computes and records the
+			// actual digest on the archive's metadata.
+			return s.service.DownloadAndProcess(egCtx, imagePath, "")
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return firmwareservice.StatusOf(err), err
+	}
+	return api.ProvisionFirmwareResponse_STATUS_OK, nil
 }
 
-func (s FirmwarePrepareState) Next() common_utils.ServiceState {
+func (s FirmwarePrepareState) Next() FirmwareState {
 	if s.service.UpdateRo() {
 		return FirmwareUpdateRoState(s)
 	} else {
@@ -6,11 +6,12 @@
 package state_machine
 
 import (
-	common_utils "chromiumos/test/provision/v2/common-utils"
 	firmwareservice "chromiumos/test/provision/v2/fw-provision/service"
 	"context"
 	"fmt"
 	"log"
+
+	"go.chromium.org/chromiumos/config/go/test/api"
 )
 
 // FirmwareUpdateRoState updates firmware with write protection disabled.
@@ -19,7 +20,7 @@ type FirmwareUpdateRoState struct {
 }
 
 // Execute flashes firmware with write-protection disabled using futility.
-func (s FirmwareUpdateRoState) Execute(ctx context.Context) error {
+func (s FirmwareUpdateRoState) Execute(ctx context.Context) (api.ProvisionFirmwareResponse_Status, error) {
 	connection := s.service.GetConnectionToFlashingDevice()
 
 	// form futility command args based on the request
@@ -42,7 +43,7 @@ func (s FirmwareUpdateRoState) Execute(ctx context.Context) error {
 			log.Printf("[FW Provisioning: Update RO] extracting AP image to flash\n")
 			mainRoPath, err := firmwareservice.PickAndExtractMainImage(ctx, connection, mainRoMetadata, s.service.GetBoard(), s.service.GetModel())
 			if err != nil {
-				return firmwareservice.UpdateFirmwareFailedErr(err.Error())
+				return firmwareservice.StatusOf(err), err
 			}
 			futilityImageArgs = append(futilityImageArgs, []string{fmt.Sprint("--image=", mainRoPath)}...)
 		}
@@ -52,7 +53,7 @@ func (s FirmwareUpdateRoState) Execute(ctx context.Context) error {
 			log.Printf("[FW Provisioning: Update RO] extracting EC image to flash\n")
 			ecRoPath, err := firmwareservice.PickAndExtractECImage(ctx, connection, ecRoMetadata, s.service.GetBoard(), s.service.GetModel())
 			if err != nil {
-				return firmwareservice.UpdateFirmwareFailedErr(err.Error())
+				return firmwareservice.StatusOf(err), err
 			}
 			if s.service.IsServoUsed() {
 				log.Printf("[FW Provisioning: Update RO] separately flashing EC over Servo with flash_ec\n")
@@ -61,11 +62,11 @@ func (s FirmwareUpdateRoState) Execute(ctx context.Context) error {
 				// use flash_ec script that to flash the EC separately.
 				flashECScript, err := firmwareservice.GetFlashECScript(ctx, connection, ecRoMetadata.ArchiveDir)
 				if err != nil {
-					return firmwareservice.UpdateFirmwareFailedErr(err.Error())
+					return firmwareservice.StatusOf(err), err
 				}
 				err = s.service.ProvisionWithFlashEC(ctx, ecRoPath, flashECScript)
 				if err != nil {
-					return firmwareservice.UpdateFirmwareFailedErr(err.Error())
+					return firmwareservice.StatusOf(err), err
 				}
 			} else {
 				// For SSH, we can simply run `futility ... --ec-image=$EC_IMAGE ...`
@@ -77,26 +78,26 @@ func (s FirmwareUpdateRoState) Execute(ctx context.Context) error {
 		if ok {
 			log.Printf("[FW Provisioning: Update RO] extracting PD image to flash\n")
 			if s.service.IsServoUsed() {
-				return firmwareservice.UpdateFirmwareFailedErr("can't flash PD as a separate image over servo")
+				err := firmwareservice.UpdateFirmwareFailedErr("can't flash PD as a separate image over servo")
+				return firmwareservice.StatusOf(err), err
 			}
 			pdRoPath, err := firmwareservice.PickAndExtractPDImage(ctx, connection, pdRoMetadata, s.service.GetBoard(), s.service.GetModel())
 			if err != nil {
-				return firmwareservice.UpdateFirmwareFailedErr(err.Error())
+				return firmwareservice.StatusOf(err), err
 			}
 			futilityImageArgs = append(futilityImageArgs, []string{fmt.Sprint("--pd_image=", pdRoPath)}...)
 		}
 	}
 
 	log.Printf("[FW Provisioning: Update RO] flashing RO firmware with futility\n")
-	err := s.service.FlashWithFutility(ctx, false /* WP */, futilityImageArgs)
-	if err != nil {
-		return firmwareservice.UpdateFirmwareFailedErr(err.Error())
+	if err := s.service.FlashWithFutility(ctx, false /* WP */, futilityImageArgs); err != nil {
+		return firmwareservice.StatusOf(err), err
 	}
 
-	return nil
+	return api.ProvisionFirmwareResponse_STATUS_OK, nil
 }
 
-func (s FirmwareUpdateRoState) Next() common_utils.ServiceState {
+func (s FirmwareUpdateRoState) Next() FirmwareState {
 	if s.service.UpdateRw() {
 		return FirmwareUpdateRwState(s)
 	} else {
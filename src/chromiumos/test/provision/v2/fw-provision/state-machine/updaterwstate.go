@@ -6,11 +6,12 @@
 package state_machine
 
 import (
-	common_utils "chromiumos/test/provision/v2/common-utils"
 	firmwareservice "chromiumos/test/provision/v2/fw-provision/service"
 	"context"
 	"fmt"
 	"log"
+
+	"go.chromium.org/chromiumos/config/go/test/api"
 )
 
 // FirmwareUpdateRwState updates firmware with write protection disabled.
@@ -19,7 +20,7 @@ type FirmwareUpdateRwState struct {
 }
 
 // Execute flashes firmware using futility with write-protection enabled.
-func (s FirmwareUpdateRwState) Execute(ctx context.Context) error {
+func (s FirmwareUpdateRwState) Execute(ctx context.Context) (api.ProvisionFirmwareResponse_Status, error) {
 	connection := s.service.GetConnectionToFlashingDevice()
 	mainRwMetadata, ok := s.service.GetImageMetadata(s.service.GetMainRwPath())
 	if !ok {
@@ -28,19 +29,18 @@ func (s FirmwareUpdateRwState) Execute(ctx context.Context) error {
 	log.Printf("[FW Provisioning: Update RW] extracting AP image to flash\n")
 	mainRwPath, err := firmwareservice.PickAndExtractMainImage(ctx, connection, mainRwMetadata, s.service.GetBoard(), s.service.GetModel())
 	if err != nil {
-		return firmwareservice.UpdateFirmwareFailedErr(err.Error())
+		return firmwareservice.StatusOf(err), err
 	}
 	futilityImageArgs := []string{fmt.Sprint("--image=", mainRwPath)}
 
 	log.Printf("[FW Provisioning: Update RW] flashing RW firmware with futility\n")
-	err = s.service.FlashWithFutility(ctx, true /* WP */, futilityImageArgs)
-	if err != nil {
-		return firmwareservice.UpdateFirmwareFailedErr(err.Error())
+	if err := s.service.FlashWithFutility(ctx, true /* WP */, futilityImageArgs); err != nil {
+		return firmwareservice.StatusOf(err), err
 	}
-	return nil
+	return api.ProvisionFirmwareResponse_STATUS_OK, nil
 }
 
-func (s FirmwareUpdateRwState) Next() common_utils.ServiceState {
+func (s FirmwareUpdateRwState) Next() FirmwareState {
 	return FirmwarePostInstallState(s)
 }
 
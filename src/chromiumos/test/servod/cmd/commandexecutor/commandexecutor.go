@@ -6,10 +6,18 @@ package commandexecutor
 
 import (
 	"bytes"
+	"context"
 	"io"
 )
 
 // This interface allows to execute a command either locally or on a remote server.
 type CommandExecutorInterface interface {
-	Run(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error)
+	// Run executes command, returning once it exits. If ctx is done before
+	// then, the command is aborted and ctx.Err() is returned.
+	Run(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error)
+
+	// RunStreaming executes a command the same way as Run, except stdout and
+	// stderr are written to the given sinks as bytes become available instead
+	// of being buffered and returned once the command exits.
+	RunStreaming(ctx context.Context, addr string, command string, stdin io.Reader, stdout, stderr io.Writer) error
 }
@@ -7,6 +7,7 @@ package commandexecutor
 import (
 	"bytes"
 	"chromiumos/test/dut/cmd/cros-dut/dutssh"
+	"context"
 	"io"
 	"log"
 	"os"
@@ -29,15 +30,16 @@ func NewServodCommandExecutor(logger *log.Logger) ServodCommandExecutor {
 }
 
 // Run executes a given command either on a remote host specified by addr
-// or locally when addr is empty or "localhost".
-func (s ServodCommandExecutor) Run(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+// or locally when addr is empty or "localhost". If ctx is done before the
+// command exits, the command is aborted and ctx.Err() is returned.
+func (s ServodCommandExecutor) Run(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 	var bOut bytes.Buffer
 	var bErr bytes.Buffer
 	var err error
 
 	localMode := addr == "" || addr == "localhost"
 	if localMode {
-		cmd := exec.Command("bash", "-c", command)
+		cmd := exec.CommandContext(ctx, "bash", "-c", command)
 
 		// Route the incoming Stdin to system Stdin
 		if stdin != nil {
@@ -55,6 +57,11 @@ func (s ServodCommandExecutor) Run(addr string, command string, stdin io.Reader,
 
 		// Run the command
 		err = cmd.Run()
+		// A command killed because ctx is done fails with "signal: killed";
+		// report ctx.Err() instead.
+		if err != nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
 	} else {
 		config := dutssh.GetSSHConfig()
 		var client *ssh.Client
@@ -85,8 +92,11 @@ func (s ServodCommandExecutor) Run(addr string, command string, stdin io.Reader,
 			session.Stderr = &bErr
 		}
 
-		// Run the command
-		err = session.Run(command)
+		// ssh.Session has no context support of its own, so run it in the
+		// background and race it against ctx: closing the session on
+		// cancellation causes Run to return early instead of waiting for the
+		// remote command to finish on its own.
+		err = s.runSessionWithContext(ctx, session, command)
 	}
 
 	// Log session stdout if it's not routed to system stdout
@@ -100,3 +110,69 @@ func (s ServodCommandExecutor) Run(addr string, command string, stdin io.Reader,
 
 	return bOut, bErr, err
 }
+
+// RunStreaming executes a given command either on a remote host specified by
+// addr or locally when addr is empty or "localhost", copying stdout/stderr to
+// the given sinks as the command produces them rather than buffering the
+// full output before returning. If ctx is done before the command exits, the
+// command is aborted and ctx.Err() is returned.
+func (s ServodCommandExecutor) RunStreaming(ctx context.Context, addr string, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	localMode := addr == "" || addr == "localhost"
+	if localMode {
+		cmd := exec.CommandContext(ctx, "bash", "-c", command)
+
+		// Route the incoming Stdin to system Stdin
+		if stdin != nil {
+			cmd.Stdin = stdin
+		}
+
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+
+		err := cmd.Run()
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	config := dutssh.GetSSHConfig()
+	client, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	// Route the incoming Stdin to system Stdin
+	if stdin != nil {
+		session.Stdin = stdin
+	}
+
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	return s.runSessionWithContext(ctx, session, command)
+}
+
+// runSessionWithContext runs command on session, returning ctx.Err() instead
+// of waiting for it to finish if ctx is done first.
+func (s ServodCommandExecutor) runSessionWithContext(ctx context.Context, session *ssh.Session, command string) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(command)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		session.Close()
+		return ctx.Err()
+	}
+}
@@ -57,14 +57,15 @@ Commands:
 
   server    Starts the servod server for RPC calls. Mostly used for tests.
             Usage:
-            cros-servod server [--log_path /tmp/servod/] [--server_port 80]
+            cros-servod server [--log_path /tmp/servod/] [--server_port 80] [--drain_timeout_seconds 60]
 
   --version Prints the version.
   
   --help    Prints the help.`
-	defaultLogDirectory = "/tmp/servod/"
-	defaultServerPort   = 80
-	defaultServodPort   = 9999
+	defaultLogDirectory        = "/tmp/servod/"
+	defaultServerPort          = 80
+	defaultServodPort          = 9999
+	defaultDrainTimeoutSeconds = 60
 )
 
 // createLogFile creates a file and its parent directory for logging purpose.
@@ -130,7 +131,7 @@ func runCLI(ctx context.Context, cs model.CliSubcommand, d []string) int {
 		return 2
 	}
 
-	if _, _, err := servodService.RunCli(cs, a, nil, true); err != nil {
+	if _, _, err := servodService.RunCli(ctx, cs, a, nil, true); err != nil {
 		logger.Fatalln("Failed to run CLI: ", err)
 		return 1
 	}
@@ -143,8 +144,11 @@ func startServer(ctx context.Context, d []string) int {
 	fs.StringVar(&a.LogPath, "log_path", defaultLogDirectory, fmt.Sprintf("The path to record execution logs. The default value is %s", defaultLogDirectory))
 	var serverPort int
 	fs.IntVar(&serverPort, "server_port", defaultServerPort, fmt.Sprintf("The port for the servod GRPC server. The default value is %d.", defaultServerPort))
+	var drainTimeoutSeconds int
+	fs.IntVar(&drainTimeoutSeconds, "drain_timeout_seconds", defaultDrainTimeoutSeconds, fmt.Sprintf("How long to wait for each active servod instance to stop during a graceful shutdown. The default value is %d.", defaultDrainTimeoutSeconds))
 	fs.Parse(d)
 	a.ServerPort = int32(serverPort)
+	a.DrainTimeoutSeconds = int32(drainTimeoutSeconds)
 
 	logFile, err := createLogFile(a.LogPath)
 	if err != nil {
@@ -163,7 +167,8 @@ func startServer(ctx context.Context, d []string) int {
 		return 2
 	}
 
-	if err := servodService.StartServer(a.ServerPort); err != nil {
+	drainTimeout := time.Duration(a.DrainTimeoutSeconds) * time.Second
+	if err := servodService.StartServer(a.ServerPort, drainTimeout); err != nil {
 		logger.Fatalln("Failed to start servod server: ", err)
 		return 1
 	}
@@ -0,0 +1,112 @@
+// Copyright 2021 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+// Package dockerclient provides an abstraction for driving a local Docker
+// daemon, used to run servod inside a container when cros-servod and
+// docker-servod live on the same host.
+package dockerclient
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+)
+
+// DockerClientInterface abstracts the docker CLI operations needed to run
+// a servod container locally: starting it detached, executing commands
+// inside it, and stopping it. It mirrors commandexecutor.CommandExecutorInterface
+// so it can be faked the same way in tests, without a real daemon.
+type DockerClientInterface interface {
+	// Run runs "docker run" with the given arguments and returns the
+	// command's stdout/stderr, e.g. the new container's ID on success.
+	Run(args []string) (bytes.Buffer, bytes.Buffer, error)
+
+	// Exec runs "docker exec" with the given arguments.
+	Exec(args []string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error)
+
+	// ExecStreaming runs "docker exec" the same way Exec does, except
+	// stdout and stderr are written to the given sinks as bytes become
+	// available instead of being buffered and returned once the command
+	// exits.
+	ExecStreaming(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+
+	// Stop runs "docker stop" followed by "docker rm" for containerName.
+	Stop(containerName string) (bytes.Buffer, bytes.Buffer, error)
+}
+
+// DockerClient acts as a receiver to implement DockerClientInterface by
+// running the "docker" binary locally through os/exec.
+type DockerClient struct {
+	logger *log.Logger
+}
+
+// NewDockerClient returns a new DockerClient.
+func NewDockerClient(logger *log.Logger) DockerClient {
+	return DockerClient{
+		logger: logger,
+	}
+}
+
+// Run implements DockerClientInterface.Run.
+func (d DockerClient) Run(args []string) (bytes.Buffer, bytes.Buffer, error) {
+	return d.run(append([]string{"run"}, args...), nil, false)
+}
+
+// Exec implements DockerClientInterface.Exec.
+func (d DockerClient) Exec(args []string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	return d.run(append([]string{"exec"}, args...), stdin, routeToStd)
+}
+
+// ExecStreaming implements DockerClientInterface.ExecStreaming.
+func (d DockerClient) ExecStreaming(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	cmd := exec.Command("docker", append([]string{"exec"}, args...)...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+// Stop implements DockerClientInterface.Stop.
+func (d DockerClient) Stop(containerName string) (bytes.Buffer, bytes.Buffer, error) {
+	bOut, bErr, err := d.run([]string{"stop", containerName}, nil, false)
+	if err != nil {
+		return bOut, bErr, err
+	}
+	return d.run([]string{"rm", containerName}, nil, false)
+}
+
+// run executes "docker" with the given arguments, either buffering its
+// stdout/stderr or routing them to the system stdout/stderr, the same way
+// commandexecutor.ServodCommandExecutor.Run does for its local-mode branch.
+func (d DockerClient) run(args []string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	var bOut, bErr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+
+	if routeToStd {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	} else {
+		cmd.Stdout = &bOut
+		cmd.Stderr = &bErr
+	}
+
+	err := cmd.Run()
+
+	if bOut.Len() > 0 {
+		d.logger.Print(bOut.String())
+	}
+	if bErr.Len() > 0 {
+		d.logger.Print(bErr.String())
+	}
+
+	return bOut, bErr, err
+}
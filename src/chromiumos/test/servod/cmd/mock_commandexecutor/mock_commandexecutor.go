@@ -5,48 +5,63 @@
 package mock_commandexecutor
 
 import (
-        bytes "bytes"
-        io "io"
-        reflect "reflect"
+	bytes "bytes"
+	context "context"
+	io "io"
+	reflect "reflect"
 
-        gomock "github.com/golang/mock/gomock"
+	gomock "github.com/golang/mock/gomock"
 )
 
 // MockCommandExecutorInterface is a mock of CommandExecutorInterface interface.
 type MockCommandExecutorInterface struct {
-        ctrl     *gomock.Controller
-        recorder *MockCommandExecutorInterfaceMockRecorder
+	ctrl     *gomock.Controller
+	recorder *MockCommandExecutorInterfaceMockRecorder
 }
 
 // MockCommandExecutorInterfaceMockRecorder is the mock recorder for MockCommandExecutorInterface.
 type MockCommandExecutorInterfaceMockRecorder struct {
-        mock *MockCommandExecutorInterface
+	mock *MockCommandExecutorInterface
 }
 
 // NewMockCommandExecutorInterface creates a new mock instance.
 func NewMockCommandExecutorInterface(ctrl *gomock.Controller) *MockCommandExecutorInterface {
-        mock := &MockCommandExecutorInterface{ctrl: ctrl}
-        mock.recorder = &MockCommandExecutorInterfaceMockRecorder{mock}
-        return mock
+	mock := &MockCommandExecutorInterface{ctrl: ctrl}
+	mock.recorder = &MockCommandExecutorInterfaceMockRecorder{mock}
+	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use.
 func (m *MockCommandExecutorInterface) EXPECT() *MockCommandExecutorInterfaceMockRecorder {
-        return m.recorder
+	return m.recorder
 }
 
 // Run mocks base method.
-func (m *MockCommandExecutorInterface) Run(addr, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
-        m.ctrl.T.Helper()
-        ret := m.ctrl.Call(m, "Run", addr, command, stdin, routeToStd)
-        ret0, _ := ret[0].(bytes.Buffer)
-        ret1, _ := ret[1].(bytes.Buffer)
-        ret2, _ := ret[2].(error)
-        return ret0, ret1, ret2
+func (m *MockCommandExecutorInterface) Run(ctx context.Context, addr, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Run", ctx, addr, command, stdin, routeToStd)
+	ret0, _ := ret[0].(bytes.Buffer)
+	ret1, _ := ret[1].(bytes.Buffer)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // Run indicates an expected call of Run.
-func (mr *MockCommandExecutorInterfaceMockRecorder) Run(addr, command, stdin, routeToStd interface{}) *gomock.Call {
-        mr.mock.ctrl.T.Helper()
-        return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockCommandExecutorInterface)(nil).Run), addr, command, stdin, routeToStd)
-}
\ No newline at end of file
+func (mr *MockCommandExecutorInterfaceMockRecorder) Run(ctx, addr, command, stdin, routeToStd interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockCommandExecutorInterface)(nil).Run), ctx, addr, command, stdin, routeToStd)
+}
+
+// RunStreaming mocks base method.
+func (m *MockCommandExecutorInterface) RunStreaming(ctx context.Context, addr, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunStreaming", ctx, addr, command, stdin, stdout, stderr)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunStreaming indicates an expected call of RunStreaming.
+func (mr *MockCommandExecutorInterfaceMockRecorder) RunStreaming(ctx, addr, command, stdin, stdout, stderr interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunStreaming", reflect.TypeOf((*MockCommandExecutorInterface)(nil).RunStreaming), ctx, addr, command, stdin, stdout, stderr)
+}
@@ -71,6 +71,10 @@ type CliArgs struct {
 
 	// The port for the servod GRPC server.
 	ServerPort int32
+
+	// How long the servod GRPC server waits for each active servod instance
+	// to stop during a graceful shutdown before giving up on it.
+	DrainTimeoutSeconds int32
 }
 
 // Subcommand for cli.
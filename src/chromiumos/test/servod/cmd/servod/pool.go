@@ -5,11 +5,30 @@
 package servod
 
 import (
+	"context"
 	"fmt"
 
+	xmlrpc_value "go.chromium.org/chromiumos/config/go/api/test/xmlrpc"
 	"go.chromium.org/luci/common/errors"
+
+	"infra/libs/sshpool"
 )
 
+// Caller is the subset of servod's behavior that CallServod/BatchCallServod
+// need: issuing a single XML-RPC call against the servod daemon. It exists
+// so callers can be faked out in tests without a real SSH connection, the
+// same way commandexecutor.CommandExecutorInterface and
+// dockerclient.DockerClientInterface are.
+type Caller interface {
+	Call(ctx context.Context, pool *sshpool.Pool, method string, args []*xmlrpc_value.Value) (*xmlrpc_value.Value, error)
+}
+
+// PoolInterface abstracts Pool for tests.
+type PoolInterface interface {
+	// Get provides servod from cache or initiate new one.
+	Get(servoAddr string, servodPort int32, getParams func() ([]string, error)) (Caller, error)
+}
+
 // Pool is a pool of servod to reuse.
 //
 // Servo are pooled by the `address:port|remote`  they are connected to.
@@ -45,11 +64,8 @@ func (p *Pool) Close() error {
 	return nil
 }
 
-// getServoParams function to receive start params for servod.
-type getServoParams func() ([]string, error)
-
 // Get provides servod from cache or initiate new one.
-func (p *Pool) Get(servoAddr string, servodPort int32, getParams getServoParams) (*servod, error) {
+func (p *Pool) Get(servoAddr string, servodPort int32, getParams func() ([]string, error)) (Caller, error) {
 	if s, ok := p.servos[createKey(servoAddr, servodPort)]; ok {
 		return s, nil
 	}
@@ -61,7 +77,7 @@ func (p *Pool) Get(servoAddr string, servodPort int32, getParams getServoParams)
 }
 
 // init creates new servod instance and places it in the cache.
-func (p *Pool) init(servoAddr string, servodPort int32, getParams getServoParams) (*servod, error) {
+func (p *Pool) init(servoAddr string, servodPort int32, getParams func() ([]string, error)) (*servod, error) {
 	if getParams == nil {
 		return nil, errors.Reason("init servod: getParams is not provided").Err()
 	}
@@ -0,0 +1,260 @@
+// Copyright 2021 The Chromium OS Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package servod
+
+import (
+	"sync"
+	"time"
+)
+
+// State describes the lifecycle state of a servod instance watched by a
+// Supervisor.
+type State string
+
+const (
+	// StateRunning is set once the most recent probe of the instance
+	// succeeded.
+	StateRunning State = "RUNNING"
+	// StateRestarting is set while a failed probe is being recovered from by
+	// restarting the instance.
+	StateRestarting State = "RESTARTING"
+	// StateFatal is set once an instance has exhausted SupervisorConfig.StartRetries
+	// within StartSeconds of its first failed probe. A Fatal instance is no
+	// longer restarted.
+	StateFatal State = "FATAL"
+)
+
+// SupervisorConfig controls how a Supervisor probes and restarts a servod
+// instance.
+type SupervisorConfig struct {
+	// ProbeInterval is the time between consecutive probes. Defaults to one
+	// minute if zero.
+	ProbeInterval time.Duration
+	// StartRetries is the number of restarts tolerated within StartSeconds of
+	// the first failed probe before the instance is marked StateFatal. Zero
+	// means unlimited retries.
+	StartRetries int
+	// StartSeconds is the grace window, starting at the first failed probe,
+	// during which up to StartRetries restarts are tolerated. Zero means the
+	// window never resets.
+	StartSeconds int
+	// BackoffBase is the delay before the first restart attempt; each
+	// subsequent restart doubles it. Defaults to one second if zero.
+	BackoffBase time.Duration
+}
+
+// Status is a point-in-time snapshot of a supervised servod instance,
+// returned by Supervisor.Status.
+type Status struct {
+	State         State
+	LastProbeTime time.Time
+	RestartCount  int
+}
+
+// Supervisor watches servod instances, probing each periodically and
+// restarting it on failure, up to a configurable number of retries within a
+// grace window.
+//
+// Supervisor is safe to use concurrently.
+type Supervisor struct {
+	mu       sync.Mutex
+	watching map[string]*watched
+}
+
+// NewSupervisor returns a new, empty Supervisor.
+func NewSupervisor() *Supervisor {
+	return &Supervisor{
+		watching: make(map[string]*watched),
+	}
+}
+
+// Watch starts supervising the servod instance identified by key (e.g.
+// "ServoHostPath|ServodPort"). probe performs a single lightweight check of
+// the instance (e.g. a dut-control call through CallServod) and returns a
+// non-nil error if it failed. restart stops and starts the instance again.
+// onState, if non-nil, is called with every state transition so callers can
+// surface it elsewhere (e.g. as longrunning.Operation metadata).
+//
+// Watch replaces any existing supervision of key.
+func (sup *Supervisor) Watch(key string, cfg SupervisorConfig, probe, restart func() error, onState func(State)) {
+	sup.mu.Lock()
+	defer sup.mu.Unlock()
+
+	if w, ok := sup.watching[key]; ok {
+		close(w.stop)
+	}
+
+	w := &watched{
+		cfg:     cfg,
+		probe:   probe,
+		restart: restart,
+		onState: onState,
+		stop:    make(chan struct{}),
+		state:   StateRunning,
+	}
+	sup.watching[key] = w
+
+	go w.run()
+}
+
+// Close stops supervising every instance currently being watched.
+func (sup *Supervisor) Close() {
+	sup.mu.Lock()
+	defer sup.mu.Unlock()
+	for key, w := range sup.watching {
+		close(w.stop)
+		delete(sup.watching, key)
+	}
+}
+
+// Unwatch stops supervising key, if it is currently being supervised.
+func (sup *Supervisor) Unwatch(key string) {
+	sup.mu.Lock()
+	defer sup.mu.Unlock()
+	if w, ok := sup.watching[key]; ok {
+		close(w.stop)
+		delete(sup.watching, key)
+	}
+}
+
+// Status returns a snapshot of the supervised instance identified by key, or
+// false if key is not currently being supervised.
+func (sup *Supervisor) Status(key string) (Status, bool) {
+	sup.mu.Lock()
+	w, ok := sup.watching[key]
+	sup.mu.Unlock()
+	if !ok {
+		return Status{}, false
+	}
+	return w.snapshot(), true
+}
+
+// watched tracks a single servod instance supervised by a Supervisor.
+type watched struct {
+	cfg SupervisorConfig
+
+	probe   func() error
+	restart func() error
+	onState func(State)
+
+	stop chan struct{}
+
+	mu            sync.Mutex
+	state         State
+	lastProbeTime time.Time
+	restartCount  int
+	firstFailure  time.Time
+	backoff       time.Duration
+}
+
+func (w *watched) snapshot() Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return Status{
+		State:         w.state,
+		LastProbeTime: w.lastProbeTime,
+		RestartCount:  w.restartCount,
+	}
+}
+
+func (w *watched) run() {
+	interval := w.cfg.ProbeInterval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.runProbe()
+		}
+	}
+}
+
+// runProbe probes the instance once and reacts to the result: a successful
+// probe resets the failure/backoff state to StateRunning, while a failed
+// probe either restarts the instance with exponential backoff or, once
+// StartRetries is exhausted within StartSeconds, marks it StateFatal.
+func (w *watched) runProbe() {
+	w.mu.Lock()
+	if w.state == StateFatal {
+		w.mu.Unlock()
+		return
+	}
+	w.mu.Unlock()
+
+	probeErr := w.probe()
+	now := time.Now()
+
+	w.mu.Lock()
+	w.lastProbeTime = now
+	if probeErr == nil {
+		w.state = StateRunning
+		w.restartCount = 0
+		w.firstFailure = time.Time{}
+		w.backoff = 0
+		state := w.state
+		w.mu.Unlock()
+		w.notify(state)
+		return
+	}
+
+	if w.firstFailure.IsZero() {
+		w.firstFailure = now
+	} else if w.cfg.StartSeconds > 0 && now.Sub(w.firstFailure) > time.Duration(w.cfg.StartSeconds)*time.Second {
+		// The grace window elapsed without exhausting StartRetries; start
+		// counting fresh from this failure.
+		w.firstFailure = now
+		w.restartCount = 0
+	}
+
+	if w.cfg.StartRetries > 0 && w.restartCount >= w.cfg.StartRetries {
+		w.state = StateFatal
+		state := w.state
+		w.mu.Unlock()
+		w.notify(state)
+		return
+	}
+
+	w.state = StateRestarting
+	w.restartCount++
+	if w.backoff == 0 {
+		w.backoff = w.cfg.BackoffBase
+		if w.backoff <= 0 {
+			w.backoff = time.Second
+		}
+	} else {
+		w.backoff *= 2
+	}
+	backoff := w.backoff
+	state := w.state
+	w.mu.Unlock()
+	w.notify(state)
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-w.stop:
+		// Unwatch/Close fired mid-backoff; don't resurrect an instance that
+		// was just told to stop.
+		return
+	case <-timer.C:
+	}
+
+	// A failed restart is left for the next probe to observe and retry; it
+	// still counts against restartCount, so it contributes towards
+	// StateFatal like any other failure would.
+	w.restart()
+}
+
+func (w *watched) notify(state State) {
+	if w.onState != nil {
+		w.onState(state)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2021 The Chromium OS Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package servod
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond every 5ms until it returns true or timeout elapses,
+// failing the test in the latter case.
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		if cond() {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %s", timeout)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Tests that a supervised instance that fails once, then recovers, is
+// restarted and transitions back to StateRunning with its restart count
+// reset.
+func TestSupervisorRecoversAfterRestart(t *testing.T) {
+	sup := NewSupervisor()
+	defer sup.Close()
+
+	var mu sync.Mutex
+	failing := true
+	restarts := 0
+	var states []State
+
+	cfg := SupervisorConfig{
+		ProbeInterval: 10 * time.Millisecond,
+		StartRetries:  3,
+		StartSeconds:  60,
+		BackoffBase:   5 * time.Millisecond,
+	}
+	sup.Watch("host|1", cfg,
+		func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			if failing {
+				return errors.New("probe failed")
+			}
+			return nil
+		},
+		func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			restarts++
+			failing = false
+			return nil
+		},
+		func(s State) {
+			mu.Lock()
+			defer mu.Unlock()
+			states = append(states, s)
+		},
+	)
+
+	waitFor(t, 2*time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return restarts > 0 && len(states) > 0 && states[len(states)-1] == StateRunning
+	})
+
+	st, ok := sup.Status("host|1")
+	if !ok {
+		t.Fatalf(`Status("host|1") not found`)
+	}
+	if st.State != StateRunning {
+		t.Errorf("Status().State = %v, want %v", st.State, StateRunning)
+	}
+	if st.RestartCount != 0 {
+		t.Errorf("Status().RestartCount = %d, want 0 once the probe recovers", st.RestartCount)
+	}
+}
+
+// Tests that a supervised instance that never recovers is marked
+// StateFatal once it exhausts StartRetries.
+func TestSupervisorMarksFatalAfterExhaustingRetries(t *testing.T) {
+	sup := NewSupervisor()
+	defer sup.Close()
+
+	var mu sync.Mutex
+	var lastState State
+
+	cfg := SupervisorConfig{
+		ProbeInterval: 10 * time.Millisecond,
+		StartRetries:  2,
+		StartSeconds:  60,
+		BackoffBase:   5 * time.Millisecond,
+	}
+	sup.Watch("host|2", cfg,
+		func() error { return errors.New("always failing") },
+		func() error { return nil },
+		func(s State) {
+			mu.Lock()
+			defer mu.Unlock()
+			lastState = s
+		},
+	)
+
+	waitFor(t, 2*time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return lastState == StateFatal
+	})
+
+	st, ok := sup.Status("host|2")
+	if !ok {
+		t.Fatalf(`Status("host|2") not found`)
+	}
+	if st.State != StateFatal {
+		t.Errorf("Status().State = %v, want %v", st.State, StateFatal)
+	}
+	if st.RestartCount < cfg.StartRetries {
+		t.Errorf("Status().RestartCount = %d, want >= %d", st.RestartCount, cfg.StartRetries)
+	}
+}
+
+// Tests that Unwatch stops probing, so no further state transitions are
+// observed afterwards.
+func TestSupervisorUnwatchStopsProbing(t *testing.T) {
+	sup := NewSupervisor()
+	defer sup.Close()
+
+	var mu sync.Mutex
+	probes := 0
+
+	cfg := SupervisorConfig{ProbeInterval: 5 * time.Millisecond}
+	sup.Watch("host|3", cfg,
+		func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			probes++
+			return nil
+		},
+		func() error { return nil },
+		nil,
+	)
+
+	waitFor(t, time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return probes > 0
+	})
+
+	sup.Unwatch("host|3")
+	if _, ok := sup.Status("host|3"); ok {
+		t.Errorf(`Status("host|3") found after Unwatch; want not found`)
+	}
+
+	mu.Lock()
+	seenAtUnwatch := probes
+	mu.Unlock()
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if probes != seenAtUnwatch {
+		t.Errorf("probe ran %d times after Unwatch; want 0", probes-seenAtUnwatch)
+	}
+}
+
+// Tests that Unwatch called while a failed probe is mid-backoff stops the
+// pending restart from firing, instead of letting it resurrect an instance
+// that was just told to stop.
+func TestSupervisorUnwatchDuringBackoffSkipsRestart(t *testing.T) {
+	sup := NewSupervisor()
+	defer sup.Close()
+
+	var mu sync.Mutex
+	probes := 0
+	restarts := 0
+
+	cfg := SupervisorConfig{
+		ProbeInterval: 5 * time.Millisecond,
+		BackoffBase:   200 * time.Millisecond,
+	}
+	sup.Watch("host|4", cfg,
+		func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			probes++
+			return errors.New("probe failed")
+		},
+		func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			restarts++
+			return nil
+		},
+		func(s State) {},
+	)
+
+	waitFor(t, time.Second, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return probes > 0 // wait until the first failed probe starts backing off
+	})
+	time.Sleep(20 * time.Millisecond) // let the probe settle into its backoff sleep
+
+	sup.Unwatch("host|4")
+
+	time.Sleep(cfg.BackoffBase * 2)
+	mu.Lock()
+	defer mu.Unlock()
+	if restarts != 0 {
+		t.Errorf("restart ran %d times after Unwatch during backoff; want 0", restarts)
+	}
+}
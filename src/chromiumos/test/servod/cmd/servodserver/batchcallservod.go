@@ -0,0 +1,100 @@
+// Copyright 2021 The Chromium OS Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package servodserver
+
+import (
+	"context"
+	"strings"
+
+	"chromiumos/test/servod/cmd/servod"
+
+	xmlrpc_value "go.chromium.org/chromiumos/config/go/api/test/xmlrpc"
+	"go.chromium.org/chromiumos/config/go/test/api"
+)
+
+// appliedSet records a SET call BatchCallServod has applied so it can be
+// undone if a later call in the same atomic batch fails: args holds the
+// target's original value, snapshotted with a GET immediately before the
+// SET was applied.
+type appliedSet struct {
+	args []*xmlrpc_value.Value
+}
+
+// BatchCallServod runs a sequence of servod commands over a single XML-RPC
+// connection, in order, for test flows that need to flip many controls at
+// once (e.g. recovery-mode sequences).
+//
+// If req.Atomic is false, every call runs regardless of earlier failures and
+// each is reported independently in the returned results.
+//
+// If req.Atomic is true, the current value of every SET target is
+// snapshotted with a GET immediately before the SET is applied. As soon as a
+// call fails, every SET already applied in this batch is undone in reverse
+// order using those snapshots, and no further calls in the batch are
+// attempted.
+//
+// Results are returned in the same order as req.Calls, covering only the
+// calls that were actually attempted.
+func (s *ServodService) BatchCallServod(ctx context.Context, req *api.BatchCallServodRequest) (*api.BatchCallServodResponse, error) {
+	s.logger.Println("Received api.BatchCallServodRequest: ", *req)
+
+	sd, err := s.servodPool.Get(
+		req.ServoHostPath,
+		req.ServodPort,
+		// This method must return non-nil value for servod.Get to work so return a dummy array.
+		func() ([]string, error) {
+			return []string{}, nil
+		})
+	if err != nil {
+		return &api.BatchCallServodResponse{
+			Results: []*api.CallServodResponse{callServodFailure(err)},
+		}, err
+	}
+
+	var results []*api.CallServodResponse
+	var applied []appliedSet
+
+	for _, call := range req.Calls {
+		var snapshot []*xmlrpc_value.Value
+		if req.Atomic && call.Method == api.CallServodRequest_SET && len(call.Args) > 0 {
+			orig, err := sd.Call(ctx, s.sshPool, "get", call.Args[:1])
+			if err != nil {
+				results = append(results, callServodFailure(err))
+				s.rollbackBatchCallServod(ctx, sd, applied)
+				return &api.BatchCallServodResponse{Results: results}, err
+			}
+			snapshot = []*xmlrpc_value.Value{call.Args[0], orig}
+		}
+
+		val, err := sd.Call(ctx, s.sshPool, strings.ToLower(call.Method.String()), call.Args)
+		if err != nil {
+			results = append(results, callServodFailure(err))
+			if req.Atomic {
+				s.rollbackBatchCallServod(ctx, sd, applied)
+				return &api.BatchCallServodResponse{Results: results}, err
+			}
+			continue
+		}
+
+		results = append(results, callServodSuccess(val))
+		if snapshot != nil {
+			applied = append(applied, appliedSet{args: snapshot})
+		}
+	}
+
+	return &api.BatchCallServodResponse{Results: results}, nil
+}
+
+// rollbackBatchCallServod undoes every SET recorded in applied, in reverse
+// order, by re-issuing it with its snapshotted original value. Rollback is
+// best-effort: a failure partway through is logged and does not stop the
+// remaining undos from being attempted.
+func (s *ServodService) rollbackBatchCallServod(ctx context.Context, sd servod.Caller, applied []appliedSet) {
+	for i := len(applied) - 1; i >= 0; i-- {
+		if _, err := sd.Call(ctx, s.sshPool, "set", applied[i].args); err != nil {
+			s.logger.Println("BatchCallServod: failed to roll back SET: ", err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2021 The Chromium OS Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package servodserver
+
+import (
+	"bytes"
+	"chromiumos/test/servod/cmd/mock_commandexecutor"
+	"chromiumos/test/servod/cmd/mock_servod"
+	"context"
+	"log"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	xmlrpc_value "go.chromium.org/chromiumos/config/go/api/test/xmlrpc"
+	"go.chromium.org/chromiumos/config/go/test/api"
+	"go.chromium.org/luci/common/errors"
+)
+
+// newTestServodService returns a ServodService backed by a MockPoolInterface
+// so BatchCallServod/CallServod can be driven without a real SSH connection.
+func newTestServodService(t *testing.T, ctrl *gomock.Controller) (srv *ServodService, mockCaller *mock_servod.MockCaller, destructor func()) {
+	t.Helper()
+
+	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
+	var logBuf bytes.Buffer
+	srv, destructor, err := NewServodService(context.Background(), log.New(&logBuf, "", log.LstdFlags|log.LUTC), mce)
+	if err != nil {
+		t.Fatalf("Failed to create new ServodService: %v", err)
+	}
+
+	mockCaller = mock_servod.NewMockCaller(ctrl)
+	mockPool := mock_servod.NewMockPoolInterface(ctrl)
+	mockPool.EXPECT().Get(gomock.Eq("servoHostPath"), gomock.Eq(int32(9901)), gomock.Any()).Return(mockCaller, nil).AnyTimes()
+	srv.servodPool = mockPool
+
+	return srv, mockCaller, destructor
+}
+
+// Tests that every call in a non-atomic batch runs regardless of earlier
+// failures, with each outcome reported independently in input order.
+func TestServodServer_BatchCallServodContinueOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv, mockCaller, destructor := newTestServodService(t, ctrl)
+	defer destructor()
+
+	gomock.InOrder(
+		mockCaller.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Eq("set"), gomock.Any()).Return(&xmlrpc_value.Value{}, nil),
+		mockCaller.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Eq("set"), gomock.Any()).Return(nil, errors.Reason("no such control").Err()),
+		mockCaller.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Eq("get"), gomock.Any()).Return(&xmlrpc_value.Value{}, nil),
+	)
+
+	resp, err := srv.BatchCallServod(context.Background(), &api.BatchCallServodRequest{
+		ServoHostPath: "servoHostPath",
+		ServodPort:    9901,
+		Atomic:        false,
+		Calls: []*api.BatchCallServodRequest_Call{
+			{Method: api.CallServodRequest_SET, Args: []*xmlrpc_value.Value{{}}},
+			{Method: api.CallServodRequest_SET, Args: []*xmlrpc_value.Value{{}}},
+			{Method: api.CallServodRequest_GET, Args: []*xmlrpc_value.Value{{}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed at api.BatchCallServod: %v", err)
+	}
+
+	if len(resp.Results) != 3 {
+		t.Fatalf("Expecting 3 results, instead got %d", len(resp.Results))
+	}
+	if resp.Results[0].GetFailure() != nil {
+		t.Fatalf("Expecting Results[0] to succeed, instead got failure %v", resp.Results[0].GetFailure())
+	}
+	if resp.Results[1].GetFailure() == nil {
+		t.Fatalf("Expecting Results[1] to fail")
+	}
+	if resp.Results[2].GetFailure() != nil {
+		t.Fatalf("Expecting Results[2] to succeed despite Results[1]'s failure, instead got failure %v", resp.Results[2].GetFailure())
+	}
+}
+
+// Tests that a mid-batch failure in an atomic batch rolls back the two prior
+// SETs, in reverse order, using their snapshotted original values, and that
+// no further calls in the batch are attempted.
+func TestServodServer_BatchCallServodAtomicRollback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	srv, mockCaller, destructor := newTestServodService(t, ctrl)
+	defer destructor()
+
+	original1 := &xmlrpc_value.Value{}
+	original2 := &xmlrpc_value.Value{}
+
+	gomock.InOrder(
+		// Call 1: SET pwr_button, snapshotted then applied.
+		mockCaller.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Eq("get"), gomock.Any()).Return(original1, nil),
+		mockCaller.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Eq("set"), gomock.Any()).Return(&xmlrpc_value.Value{}, nil),
+		// Call 2: SET lid_open, snapshotted then applied.
+		mockCaller.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Eq("get"), gomock.Any()).Return(original2, nil),
+		mockCaller.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Eq("set"), gomock.Any()).Return(&xmlrpc_value.Value{}, nil),
+		// Call 3: SET cold_reset, snapshotted, then fails to apply.
+		mockCaller.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Eq("get"), gomock.Any()).Return(&xmlrpc_value.Value{}, nil),
+		mockCaller.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Eq("set"), gomock.Any()).Return(nil, errors.Reason("no such control").Err()),
+		// Rollback: undo call 2's SET, then call 1's, in reverse order.
+		mockCaller.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Eq("set"), gomock.Eq([]*xmlrpc_value.Value{{}, original2})),
+		mockCaller.EXPECT().Call(gomock.Any(), gomock.Any(), gomock.Eq("set"), gomock.Eq([]*xmlrpc_value.Value{{}, original1})),
+	)
+
+	resp, err := srv.BatchCallServod(context.Background(), &api.BatchCallServodRequest{
+		ServoHostPath: "servoHostPath",
+		ServodPort:    9901,
+		Atomic:        true,
+		Calls: []*api.BatchCallServodRequest_Call{
+			{Method: api.CallServodRequest_SET, Args: []*xmlrpc_value.Value{{}, {}}},
+			{Method: api.CallServodRequest_SET, Args: []*xmlrpc_value.Value{{}, {}}},
+			{Method: api.CallServodRequest_SET, Args: []*xmlrpc_value.Value{{}, {}}},
+			{Method: api.CallServodRequest_SET, Args: []*xmlrpc_value.Value{{}, {}}},
+		},
+	})
+	if err == nil {
+		t.Fatalf("Should have failed at api.BatchCallServod.")
+	}
+
+	// Only the 3 attempted calls are reported; the 4th is never attempted.
+	if len(resp.Results) != 3 {
+		t.Fatalf("Expecting 3 results, instead got %d", len(resp.Results))
+	}
+	if resp.Results[0].GetFailure() != nil || resp.Results[1].GetFailure() != nil {
+		t.Fatalf("Expecting the first two calls to have succeeded before the rollback")
+	}
+	if resp.Results[2].GetFailure() == nil {
+		t.Fatalf("Expecting the third call to report the failure that triggered rollback")
+	}
+}
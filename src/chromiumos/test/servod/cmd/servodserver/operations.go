@@ -0,0 +1,100 @@
+// Copyright 2021 The Chromium OS Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package servodserver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"go.chromium.org/chromiumos/config/go/longrunning"
+)
+
+// servoHostPathFilterPrefix is an additional ListOperations filter clause,
+// on top of whatever lro.Manager itself supports, since lro.Manager has no
+// notion of ServoHostPath.
+const servoHostPathFilterPrefix = "servo_host_path:"
+
+// GetOperation returns the requested longrunning.Operation, delegating to
+// the underlying lro.Manager.
+func (s *ServodService) GetOperation(ctx context.Context, req *longrunning.GetOperationRequest) (*longrunning.Operation, error) {
+	return s.manager.GetOperation(ctx, req)
+}
+
+// DeleteOperation deletes the requested longrunning.Operation, delegating to
+// the underlying lro.Manager, and forgets the ServoHostPath it was created
+// for so opServoHostPath doesn't keep an entry for an operation that no
+// longer exists.
+func (s *ServodService) DeleteOperation(ctx context.Context, req *longrunning.DeleteOperationRequest) (*empty.Empty, error) {
+	resp, err := s.manager.DeleteOperation(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.forgetOpServoHostPath(req.Name)
+	return resp, nil
+}
+
+// WaitOperation waits for the requested longrunning.Operation to either
+// complete or hit its timeout, delegating to the underlying lro.Manager.
+// Clients use this to poll a StartServod that's still provisioning servod:
+// the operation stays open, with its Metadata tracking supervision state,
+// until the instance goes fatal or is stopped.
+func (s *ServodService) WaitOperation(ctx context.Context, req *longrunning.WaitOperationRequest) (*longrunning.Operation, error) {
+	return s.manager.WaitOperation(ctx, req)
+}
+
+// ListOperations lists operations, delegating most of req.Filter to
+// lro.Manager and additionally supporting a "servo_host_path:VALUE" clause
+// to filter by the instance a StartServod/StopServod operation was created
+// for. Pagination is delegated to lro.Manager as-is, so the
+// servo_host_path clause is applied within a page at a time rather than
+// across the whole result set.
+func (s *ServodService) ListOperations(ctx context.Context, req *longrunning.ListOperationsRequest) (*longrunning.ListOperationsResponse, error) {
+	var servoHostPath string
+	hasServoHostPath := false
+	var otherClauses []string
+	for _, clause := range strings.Fields(req.Filter) {
+		if strings.HasPrefix(clause, servoHostPathFilterPrefix) {
+			servoHostPath = strings.TrimPrefix(clause, servoHostPathFilterPrefix)
+			hasServoHostPath = true
+			continue
+		}
+		otherClauses = append(otherClauses, clause)
+	}
+	if !hasServoHostPath {
+		return s.manager.ListOperations(ctx, req)
+	}
+
+	delegateReq := *req
+	delegateReq.Filter = strings.Join(otherClauses, " ")
+	resp, err := s.manager.ListOperations(ctx, &delegateReq)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := resp.Operations[:0]
+	for _, op := range resp.Operations {
+		if s.opHasServoHostPath(op.Name, servoHostPath) {
+			filtered = append(filtered, op)
+		}
+	}
+	resp.Operations = filtered
+	return resp, nil
+}
+
+// CancelOperation aborts the in-flight CommandExecutorInterface.Run call
+// backing req.Name, if StartServod or StopServod is still blocked on one:
+// it looks up the context.CancelFunc that StartServod/StopServod registered
+// for it and calls it, which causes RunCli to return ctx.Err() and the
+// caller, still running inside StartServod/StopServod, to mark the
+// operation done with a Canceled error. If no command is currently in
+// flight for req.Name (e.g. it's already past provisioning and being
+// supervised, or already done), this is a no-op.
+func (s *ServodService) CancelOperation(ctx context.Context, req *longrunning.CancelOperationRequest) (*empty.Empty, error) {
+	if cancel, ok := s.lookupOpCancel(req.Name); ok {
+		cancel()
+	}
+	return &empty.Empty{}, nil
+}
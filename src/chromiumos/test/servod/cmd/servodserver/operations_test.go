@@ -0,0 +1,120 @@
+// Copyright 2021 The Chromium OS Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package servodserver
+
+import (
+	"bytes"
+	"chromiumos/test/servod/cmd/mock_commandexecutor"
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"go.chromium.org/chromiumos/config/go/longrunning"
+	"go.chromium.org/chromiumos/config/go/test/api"
+)
+
+// Tests that CancelOperation unblocks a StartServod that is stuck in a still
+// in-flight RunCli call, by canceling the context it's running under, and
+// that the operation is then marked done with an Operation_Error rather than
+// left hanging.
+func TestServodServer_CancelOperationUnblocksStartServod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
+
+	unblock := make(chan struct{})
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+			close(unblock)
+			<-ctx.Done()
+			return bytes.Buffer{}, bytes.Buffer{}, ctx.Err()
+		},
+	)
+
+	ctx := context.Background()
+	var logBuf bytes.Buffer
+	srv, destructor, err := NewServodService(ctx, log.New(&logBuf, "", log.LstdFlags|log.LUTC), mce)
+	defer destructor()
+	if err != nil {
+		t.Fatalf("Failed to create new ServodService: %v", err)
+	}
+
+	startServodErr := make(chan error, 1)
+	go func() {
+		_, err := srv.StartServod(ctx, &api.StartServodRequest{
+			ServoHostPath: "servoHostPath",
+			ServodPort:    0,
+			Board:         "board",
+			Model:         "model",
+			SerialName:    "serialname",
+		})
+		startServodErr <- err
+	}()
+
+	select {
+	case <-unblock:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("StartServod's underlying Run was never called")
+	}
+
+	// StartServod is still blocked inside RunCli at this point, so its
+	// operation isn't returned yet; find it by listing not-yet-done
+	// operations instead.
+	list, err := srv.ListOperations(ctx, &longrunning.ListOperationsRequest{Filter: "done=false"})
+	if err != nil {
+		t.Fatalf("Failed at api.ListOperations: %v", err)
+	}
+	if len(list.Operations) != 1 {
+		t.Fatalf("Expecting exactly one pending operation, instead got %d", len(list.Operations))
+	}
+	opName := list.Operations[0].Name
+
+	if _, err := srv.CancelOperation(ctx, &longrunning.CancelOperationRequest{Name: opName}); err != nil {
+		t.Fatalf("Failed at api.CancelOperation: %v", err)
+	}
+
+	if err := <-startServodErr; err == nil {
+		t.Fatalf("Expecting StartServod to return an error once its RunCli call is canceled")
+	}
+
+	got, err := srv.GetOperation(ctx, &longrunning.GetOperationRequest{Name: opName})
+	if err != nil {
+		t.Fatalf("Failed at api.GetOperation: %v", err)
+	}
+	if !got.Done {
+		t.Fatalf("Expecting operation to be done after CancelOperation, instead got %v", got)
+	}
+	switch got.Result.(type) {
+	case *longrunning.Operation_Error:
+	default:
+		t.Fatalf("Expecting operation Result to be Operation_Error, instead got %T", got.Result)
+	}
+}
+
+// Tests that CancelOperation against an operation with no in-flight RunCli
+// call (e.g. a servod instance that's already being supervised) is a no-op,
+// rather than an error.
+func TestServodServer_CancelOperationNoOpOnceNotInFlight(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
+
+	ctx := context.Background()
+	var logBuf bytes.Buffer
+	srv, destructor, err := NewServodService(ctx, log.New(&logBuf, "", log.LstdFlags|log.LUTC), mce)
+	defer destructor()
+	if err != nil {
+		t.Fatalf("Failed to create new ServodService: %v", err)
+	}
+
+	if _, err := srv.CancelOperation(ctx, &longrunning.CancelOperationRequest{Name: "operations/does-not-exist"}); err != nil {
+		t.Fatalf("Expecting CancelOperation against an unknown/finished operation to be a no-op, instead got: %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2021 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package servodserver
+
+import (
+	"bytes"
+	"chromiumos/test/servod/cmd/commandexecutor"
+	"chromiumos/test/servod/cmd/dockerclient"
+	"chromiumos/test/servod/cmd/model"
+	"context"
+	"fmt"
+	"io"
+
+	"go.chromium.org/luci/common/errors"
+)
+
+// ServodRuntime abstracts where RunCli/RunCliStreaming's subcommands
+// actually execute. newServodRuntime selects the implementation once per
+// request.
+type ServodRuntime interface {
+	// Run runs cs the same way RunCli does, buffering stdout/stderr. If ctx
+	// is done before the command exits, the command is aborted and
+	// ctx.Err() is returned; dockerRuntime does not support this yet and
+	// ignores ctx.
+	Run(ctx context.Context, cs model.CliSubcommand, a model.CliArgs, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error)
+
+	// RunStreaming is Run, except stdout/stderr are written to the given
+	// sinks as bytes become available instead of being buffered and
+	// returned once the command exits.
+	RunStreaming(ctx context.Context, cs model.CliSubcommand, a model.CliArgs, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+// newServodRuntime returns dockerRuntime when cros-servod and docker-servod
+// are expected to share a host - ServoHostPath is empty, per the convention
+// documented on model.CliArgs.ServoHostPath - and a.ServodDockerContainerName
+// is set. Otherwise it returns sshRuntime, which preserves the prior
+// behavior of sending the already-built command, Docker or not, to
+// commandexecutor over SSH (or locally, through the same executor, when
+// ServoHostPath is empty and no Docker fields are set).
+func (s *ServodService) newServodRuntime(a model.CliArgs) ServodRuntime {
+	if a.ServoHostPath == "" && a.ServodDockerContainerName != "" {
+		return &dockerRuntime{docker: s.dockerClient}
+	}
+	return &sshRuntime{commandexecutor: s.commandexecutor}
+}
+
+// sshRuntime builds the command for cs the same way the original RunCli did
+// and sends it to commandexecutor.CommandExecutorInterface, addressed at
+// a.ServoHostPath.
+type sshRuntime struct {
+	commandexecutor commandexecutor.CommandExecutorInterface
+}
+
+func (r *sshRuntime) command(cs model.CliSubcommand, a model.CliArgs) (string, error) {
+	switch cs {
+	case model.CliStartServod:
+		return getStartServodCommand(a)
+	case model.CliStopServod:
+		return getStopServodCommand(a), nil
+	case model.CliExecCmd:
+		return getExecCmdCommand(a), nil
+	case model.CliCallServod:
+		return getCallServodCommand(a), nil
+	}
+	return "", nil
+}
+
+func (r *sshRuntime) Run(ctx context.Context, cs model.CliSubcommand, a model.CliArgs, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	command, err := r.command(cs, a)
+	if err != nil || command == "" {
+		return bytes.Buffer{}, bytes.Buffer{}, err
+	}
+	return r.commandexecutor.Run(ctx, a.ServoHostPath, command, stdin, routeToStd)
+}
+
+func (r *sshRuntime) RunStreaming(ctx context.Context, cs model.CliSubcommand, a model.CliArgs, stdin io.Reader, stdout, stderr io.Writer) error {
+	command, err := r.command(cs, a)
+	if err != nil || command == "" {
+		return err
+	}
+	return r.commandexecutor.RunStreaming(ctx, a.ServoHostPath, command, stdin, stdout, stderr)
+}
+
+// dockerRuntime runs servod inside a local Docker container through
+// dockerclient.DockerClientInterface, rather than shelling a "docker ..."
+// string out over SSH: it builds docker's argv directly, so tests can
+// assert on it without a shell round-trip.
+type dockerRuntime struct {
+	docker dockerclient.DockerClientInterface
+}
+
+// dockerRunArgs returns the arguments for "docker run -d" that start a.
+// ServoHostPath being empty means the servo's USB device lives on this same
+// host, so it's passed through to the container instead of relying on
+// --network host plus a remote SSH hop the way sshRuntime's Docker commands
+// do.
+func dockerRunArgs(a model.CliArgs) []string {
+	args := []string{
+		"-d",
+		"--name", a.ServodDockerContainerName,
+		"--device=/dev/bus/usb",
+		"-p", fmt.Sprintf("%d:%d", a.ServodPort, a.ServodPort),
+		"-e", fmt.Sprintf("PORT=%d", a.ServodPort),
+		"-e", fmt.Sprintf("BOARD=%s", a.Board),
+		"-e", fmt.Sprintf("MODEL=%s", a.Model),
+		"-e", fmt.Sprintf("SERIAL=%s", a.SerialName),
+	}
+	if a.AllowDualV4 != "" {
+		args = append(args, "-e", fmt.Sprintf("DUAL_V4=%s", a.AllowDualV4))
+	}
+	if a.Config != "" {
+		args = append(args, "-e", fmt.Sprintf("CONFIG=%s", a.Config))
+	}
+	if a.Debug != "" {
+		args = append(args, "-e", fmt.Sprintf("DEBUG=%s", a.Debug))
+	}
+	if a.RecoveryMode != "" {
+		args = append(args, "-e", fmt.Sprintf("REC_MODE=%s", a.RecoveryMode))
+	}
+	return append(args, a.ServodDockerImagePath)
+}
+
+// dockerExecArgs returns the arguments for "docker exec" that run command
+// inside a.ServodDockerContainerName.
+func dockerExecArgs(a model.CliArgs, command string) []string {
+	return []string{a.ServodDockerContainerName, "bash", "-c", command}
+}
+
+func (r *dockerRuntime) Run(ctx context.Context, cs model.CliSubcommand, a model.CliArgs, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	switch cs {
+	case model.CliStartServod:
+		if err := validateStartServodArgs(a); err != nil {
+			return bytes.Buffer{}, bytes.Buffer{}, err
+		}
+		return r.docker.Run(dockerRunArgs(a))
+	case model.CliStopServod:
+		if a.ServodDockerContainerName == "" {
+			return bytes.Buffer{}, bytes.Buffer{}, errors.Reason("ServodDockerContainerName not specified").Err()
+		}
+		return r.docker.Stop(a.ServodDockerContainerName)
+	case model.CliExecCmd:
+		return r.docker.Exec(dockerExecArgs(a, a.Command), stdin, routeToStd)
+	case model.CliCallServod:
+		return r.docker.Exec(dockerExecArgs(a, dutControlCommand(a)), stdin, routeToStd)
+	}
+	return bytes.Buffer{}, bytes.Buffer{}, nil
+}
+
+func (r *dockerRuntime) RunStreaming(ctx context.Context, cs model.CliSubcommand, a model.CliArgs, stdin io.Reader, stdout, stderr io.Writer) error {
+	switch cs {
+	case model.CliStartServod:
+		if err := validateStartServodArgs(a); err != nil {
+			return err
+		}
+		_, _, err := r.docker.Run(dockerRunArgs(a))
+		return err
+	case model.CliStopServod:
+		if a.ServodDockerContainerName == "" {
+			return errors.Reason("ServodDockerContainerName not specified").Err()
+		}
+		_, _, err := r.docker.Stop(a.ServodDockerContainerName)
+		return err
+	case model.CliExecCmd:
+		return r.docker.ExecStreaming(dockerExecArgs(a, a.Command), stdin, stdout, stderr)
+	case model.CliCallServod:
+		return r.docker.ExecStreaming(dockerExecArgs(a, dutControlCommand(a)), stdin, stdout, stderr)
+	}
+	return nil
+}
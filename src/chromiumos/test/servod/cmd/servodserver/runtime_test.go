@@ -0,0 +1,228 @@
+// Copyright 2021 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package servodserver
+
+import (
+	"bytes"
+	"chromiumos/test/servod/cmd/mock_dockerclient"
+	"chromiumos/test/servod/cmd/model"
+	"context"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
+
+// newTestDockerServodService returns a ServodService wired to mdc, the way
+// NewServodService wires one to a real DockerClient, for tests that only
+// care about dockerRuntime.
+func newTestDockerServodService(mdc *mock_dockerclient.MockDockerClientInterface) *ServodService {
+	var logBuf bytes.Buffer
+	return &ServodService{
+		logger:       log.New(&logBuf, "", log.LstdFlags|log.LUTC),
+		dockerClient: mdc,
+	}
+}
+
+// Tests that a local Dockerized servod starts with the board/model/serial
+// combination turned into the right "docker run" argv, including servo USB
+// device passthrough and the servod port exposed on the host.
+func TestServodCLI_StartServodDockerRuntimeSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mdc := mock_dockerclient.NewMockDockerClientInterface(ctrl)
+
+	expectedArgs := []string{
+		"-d",
+		"--name", "servodDockerContainerName",
+		"--device=/dev/bus/usb",
+		"-p", "1234:1234",
+		"-e", "PORT=1234",
+		"-e", "BOARD=board",
+		"-e", "MODEL=model",
+		"-e", "SERIAL=serialname",
+		"servodDockerImagePath",
+	}
+
+	mdc.EXPECT().Run(gomock.Eq(expectedArgs)).DoAndReturn(
+		func(args []string) (bytes.Buffer, bytes.Buffer, error) {
+			var bOut bytes.Buffer
+			bOut.Write([]byte("containerid"))
+			return bOut, bytes.Buffer{}, nil
+		},
+	)
+
+	srv := newTestDockerServodService(mdc)
+
+	a := model.CliArgs{
+		ServodDockerContainerName: "servodDockerContainerName",
+		ServodDockerImagePath:     "servodDockerImagePath",
+		ServodPort:                1234,
+		Board:                     "board",
+		Model:                     "model",
+		SerialName:                "serialname",
+	}
+
+	bOut, _, err := srv.RunCli(context.Background(), model.CliStartServod, a, nil, false)
+	if err != nil {
+		t.Fatalf("Failed at api.RunCli: %v", err)
+	}
+	if bOut.String() != "containerid" {
+		t.Fatalf("Expecting bOut to be \"containerid\", instead got %v", bOut.String())
+	}
+}
+
+// Tests that a local Dockerized servod start requires ServodDockerContainerName.
+func TestServodCLI_StartServodDockerRuntimeWithoutContainerName(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mdc := mock_dockerclient.NewMockDockerClientInterface(ctrl)
+	srv := newTestDockerServodService(mdc)
+
+	a := model.CliArgs{
+		ServodDockerImagePath: "servodDockerImagePath",
+		ServodPort:            1234,
+		Board:                 "board",
+		Model:                 "model",
+		SerialName:            "serialname",
+	}
+
+	// With no ServodDockerContainerName, newServodRuntime falls back to
+	// sshRuntime, so this must fail the same way the ssh path does rather
+	// than reach mdc at all.
+	_, _, err := srv.RunCli(context.Background(), model.CliStartServod, a, nil, false)
+	if err == nil {
+		t.Fatalf("Should have failed at api.RunCli.")
+	}
+	if err.Error() != "ServodDockerContainerName not specified" {
+		t.Fatalf("Expecting error reason to be \"ServodDockerContainerName not specified\", instead got %v", err.Error())
+	}
+}
+
+// Tests that a local Dockerized servod stops via "docker stop"/"docker rm".
+func TestServodCLI_StopServodDockerRuntimeSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mdc := mock_dockerclient.NewMockDockerClientInterface(ctrl)
+	mdc.EXPECT().Stop(gomock.Eq("servodDockerContainerName")).DoAndReturn(
+		func(containerName string) (bytes.Buffer, bytes.Buffer, error) {
+			return bytes.Buffer{}, bytes.Buffer{}, nil
+		},
+	)
+
+	srv := newTestDockerServodService(mdc)
+
+	a := model.CliArgs{
+		ServodDockerContainerName: "servodDockerContainerName",
+		ServodPort:                1234,
+	}
+
+	if _, _, err := srv.RunCli(context.Background(), model.CliStopServod, a, nil, false); err != nil {
+		t.Fatalf("Failed at api.RunCli: %v", err)
+	}
+}
+
+// Tests that ExecCmd against a locally Dockerized servod runs through
+// "docker exec" for the right container/board/model/serial combination.
+func TestServodCLI_ExecCmdDockerRuntimeSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mdc := mock_dockerclient.NewMockDockerClientInterface(ctrl)
+	mdc.EXPECT().Exec(gomock.Eq([]string{"servodDockerContainerName", "bash", "-c", "command"}), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(args []string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+			var bOut bytes.Buffer
+			bOut.Write([]byte("success!"))
+			return bOut, bytes.Buffer{}, nil
+		},
+	)
+
+	srv := newTestDockerServodService(mdc)
+
+	a := model.CliArgs{
+		ServodDockerContainerName: "servodDockerContainerName",
+		Command:                   "command",
+	}
+
+	bOut, _, err := srv.RunCli(context.Background(), model.CliExecCmd, a, nil, false)
+	if err != nil {
+		t.Fatalf("Failed at api.RunCli: %v", err)
+	}
+	if bOut.String() != "success!" {
+		t.Fatalf("Expecting bOut to be \"success!\", instead got %v", bOut.String())
+	}
+}
+
+// Tests that CallServod's GET method against a locally Dockerized servod
+// runs dut-control through "docker exec".
+func TestServodCLI_CallServodDockerRuntimeGetSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mdc := mock_dockerclient.NewMockDockerClientInterface(ctrl)
+	mdc.EXPECT().Exec(gomock.Eq([]string{"servodDockerContainerName", "bash", "-c", "dut-control -p 1234 args"}), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(args []string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+			var bOut bytes.Buffer
+			bOut.Write([]byte("success!"))
+			return bOut, bytes.Buffer{}, nil
+		},
+	)
+
+	srv := newTestDockerServodService(mdc)
+
+	a := model.CliArgs{
+		ServodDockerContainerName: "servodDockerContainerName",
+		ServodPort:                1234,
+		Method:                    "GET",
+		Args:                      "args",
+	}
+
+	bOut, _, err := srv.RunCli(context.Background(), model.CliCallServod, a, nil, false)
+	if err != nil {
+		t.Fatalf("Failed at api.RunCli: %v", err)
+	}
+	if bOut.String() != "success!" {
+		t.Fatalf("Expecting bOut to be \"success!\", instead got %v", bOut.String())
+	}
+}
+
+// Tests that ExecCmd streaming against a locally Dockerized servod streams
+// stdout/stderr through "docker exec" instead of buffering.
+func TestServodCLI_ExecCmdDockerRuntimeStreamingSuccess(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mdc := mock_dockerclient.NewMockDockerClientInterface(ctrl)
+
+	var gotStdout, gotStderr bytes.Buffer
+	mdc.EXPECT().ExecStreaming(gomock.Eq([]string{"servodDockerContainerName", "bash", "-c", "command"}), gomock.Eq(nil), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+			stdout.Write([]byte("out"))
+			stderr.Write([]byte("err"))
+			return nil
+		},
+	)
+
+	srv := newTestDockerServodService(mdc)
+
+	a := model.CliArgs{
+		ServodDockerContainerName: "servodDockerContainerName",
+		Command:                   "command",
+	}
+
+	if err := srv.RunCliStreaming(context.Background(), model.CliExecCmd, a, nil, &gotStdout, &gotStderr); err != nil {
+		t.Fatalf("Failed at api.RunCliStreaming: %v", err)
+	}
+	if gotStdout.String() != "out" {
+		t.Fatalf("Expecting stdout to be \"out\", instead got %v", gotStdout.String())
+	}
+	if gotStderr.String() != "err" {
+		t.Fatalf("Expecting stderr to be \"err\", instead got %v", gotStderr.String())
+	}
+}
@@ -9,21 +9,36 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"infra/libs/sshpool"
 	"io"
 	"log"
 	"strings"
+	"sync"
+	"time"
 
 	"chromiumos/lro"
 	"chromiumos/test/servod/cmd/commandexecutor"
+	"chromiumos/test/servod/cmd/dockerclient"
 	"chromiumos/test/servod/cmd/model"
 	"chromiumos/test/servod/cmd/servod"
 
 	"chromiumos/test/servod/cmd/ssh"
 
+	xmlrpc_value "go.chromium.org/chromiumos/config/go/api/test/xmlrpc"
 	"go.chromium.org/chromiumos/config/go/longrunning"
 	"go.chromium.org/chromiumos/config/go/test/api"
 	crypto_ssh "golang.org/x/crypto/ssh"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// Defaults applied to a StartServodRequest's supervision parameters when
+// left unset.
+const (
+	defaultProbeInterval = 30 * time.Second
+	defaultStartRetries  = 3
+	defaultStartSeconds  = 300
 )
 
 // ServodService implementation of servod_service.proto
@@ -31,8 +46,38 @@ type ServodService struct {
 	manager         *lro.Manager
 	logger          *log.Logger
 	commandexecutor commandexecutor.CommandExecutorInterface
+	dockerClient    dockerclient.DockerClientInterface
 	sshPool         *sshpool.Pool
-	servodPool      *servod.Pool
+	servodPool      servod.PoolInterface
+	supervisor      *servod.Supervisor
+
+	activeMu sync.Mutex
+	// active holds the StartServodRequest for every servod instance that
+	// has been started and not yet stopped, keyed by supervisorKey. It
+	// backs GracefulShutdown, which needs enough of the original request
+	// (e.g. ServodDockerContainerName) to call StopServod on each.
+	active map[string]*api.StartServodRequest
+	// startOp holds, for every servod instance currently in active, the
+	// name of the StartServod operation supervising it, keyed by
+	// supervisorKey. StopServod looks this up so it can complete that same
+	// operation instead of leaving it open forever.
+	startOp map[string]string
+
+	opMu sync.Mutex
+	// opCancel holds, for every StartServod/StopServod operation whose
+	// RunCli call is still in flight, the context.CancelFunc that aborts
+	// it. CancelOperation looks an entry up by operation name so a hung SSH
+	// exec can be unblocked instead of waiting for it to time out.
+	opCancel map[string]context.CancelFunc
+	// opServoHostPath holds, for every StartServod/StopServod operation
+	// lro.Manager still knows about, the ServoHostPath it was created for,
+	// so ListOperations can filter by it even though lro.Manager has no
+	// notion of it. Entries are forgotten once their operation is deleted
+	// or expires; see forgetOpServoHostPath and pruneOpServoHostPath.
+	opServoHostPath map[string]string
+	// opPruneStopper signals pruneOpServoHostPath's periodic goroutine to
+	// terminate.
+	opPruneStopper chan struct{}
 }
 
 // NewServodService creates a new servod service.
@@ -41,11 +86,31 @@ func NewServodService(ctx context.Context, logger *log.Logger, commandexecutor c
 		manager:         lro.New(),
 		logger:          logger,
 		commandexecutor: commandexecutor,
+		dockerClient:    dockerclient.NewDockerClient(logger),
 		sshPool:         sshpool.New(ssh.SSHConfig()),
 		servodPool:      servod.NewPool(),
+		supervisor:      servod.NewSupervisor(),
+		active:          make(map[string]*api.StartServodRequest),
+		startOp:         make(map[string]string),
+		opCancel:        make(map[string]context.CancelFunc),
+		opServoHostPath: make(map[string]string),
+		opPruneStopper:  make(chan struct{}),
 	}
 
+	go func() {
+		for {
+			select {
+			case <-servodService.opPruneStopper:
+				return
+			case <-time.After(time.Hour):
+				servodService.pruneOpServoHostPath(context.Background())
+			}
+		}
+	}()
+
 	destructor := func() {
+		close(servodService.opPruneStopper)
+		servodService.supervisor.Close()
 		servodService.manager.Close()
 	}
 
@@ -73,23 +138,226 @@ func (s *ServodService) StartServod(ctx context.Context, req *api.StartServodReq
 		AllowDualV4:               req.AllowDualV4,
 	}
 
-	_, bErr, err := s.RunCli(model.CliStartServod, a, nil, false)
+	// opCtx, derived from ctx, guards the RunCli call below: it still dies
+	// if ctx carries a deadline (e.g. GracefulShutdown's drainTimeout), but
+	// CancelOperation can also abort it independently from a later RPC
+	// without waiting for ctx itself to end.
+	opCtx, cancel := context.WithCancel(ctx)
+	s.registerOpCancel(op.Name, req.ServoHostPath, cancel)
+	defer s.unregisterOpCancel(op.Name)
+
+	_, bErr, err := s.RunCli(opCtx, model.CliStartServod, a, nil, false)
 	if err != nil {
 		s.logger.Println("Failed to run CLI: ", err)
-		s.manager.SetResult(op.Name, &api.StartServodResponse{
-			Result: &api.StartServodResponse_Failure_{
-				Failure: &api.StartServodResponse_Failure{
-					ErrorMessage: getErrorMessage(bErr, err),
+		if opCtx.Err() != nil {
+			if serr := s.manager.SetError(op.Name, grpcstatus.New(codes.Canceled, getErrorMessage(bErr, err))); serr != nil {
+				s.logger.Println("Failed to set operation error: ", serr)
+			}
+		} else {
+			s.manager.SetResult(op.Name, &api.StartServodResponse{
+				Result: &api.StartServodResponse_Failure_{
+					Failure: &api.StartServodResponse_Failure{
+						ErrorMessage: getErrorMessage(bErr, err),
+					},
 				},
-			},
-		})
-	} else {
-		s.manager.SetResult(op.Name, &api.StartServodResponse{
-			Result: &api.StartServodResponse_Success_{},
+			})
+		}
+		return op, err
+	}
+
+	// Leave op open rather than completing it with Success here: its
+	// Metadata now tracks the instance's supervision state for as long as
+	// it's running, and op only completes once supervision ends, either
+	// because the instance is marked servod.StateFatal or because a later
+	// StopServod call completes it.
+	s.superviseServod(op.Name, req, a)
+
+	return op, nil
+}
+
+// superviseServod starts supervising the servod instance just started by
+// req, probing it periodically through CallServod and restarting it on
+// failure. Every state transition is recorded as op's Metadata; if the
+// instance is ultimately marked servod.StateFatal, op is completed with that
+// error so clients polling it can observe the terminal failure.
+func (s *ServodService) superviseServod(opName string, req *api.StartServodRequest, a model.CliArgs) {
+	key := supervisorKey(req.ServoHostPath, req.ServodPort)
+
+	cfg := servod.SupervisorConfig{
+		ProbeInterval: defaultProbeInterval,
+		StartRetries:  int(req.StartRetries),
+		StartSeconds:  int(req.StartSeconds),
+	}
+	if cfg.StartRetries == 0 {
+		cfg.StartRetries = defaultStartRetries
+	}
+	if cfg.StartSeconds == 0 {
+		cfg.StartSeconds = defaultStartSeconds
+	}
+
+	probe := func() error {
+		resp, err := s.CallServod(context.Background(), &api.CallServodRequest{
+			ServoHostPath: req.ServoHostPath,
+			ServodPort:    req.ServodPort,
+			Method:        api.CallServodRequest_DOC,
 		})
+		if err != nil {
+			return err
+		}
+		if f := resp.GetFailure(); f != nil {
+			return errors.New(f.ErrorMessage)
+		}
+		return nil
 	}
 
-	return op, err
+	restart := func() error {
+		if _, bErr, err := s.RunCli(context.Background(), model.CliStopServod, a, nil, false); err != nil {
+			s.logger.Println("Supervisor: failed to stop servod for restart: ", getErrorMessage(bErr, err))
+		}
+		_, bErr, err := s.RunCli(context.Background(), model.CliStartServod, a, nil, false)
+		if err != nil {
+			s.logger.Println("Supervisor: failed to restart servod: ", getErrorMessage(bErr, err))
+		}
+		return err
+	}
+
+	onState := func(st servod.State) {
+		if err := s.manager.SetMetadata(opName, &api.ServodStatus{State: string(st)}); err != nil {
+			s.logger.Println("Supervisor: failed to update operation metadata: ", err)
+		}
+		if st == servod.StateFatal {
+			if err := s.manager.SetError(opName, grpcstatus.New(codes.Internal, fmt.Sprintf("servod %s is fatal after exhausting start retries", key))); err != nil {
+				s.logger.Println("Supervisor: failed to set operation error: ", err)
+			}
+		}
+	}
+
+	s.registerActive(key, req, opName)
+	s.supervisor.Watch(key, cfg, probe, restart, onState)
+}
+
+// registerActive records req as the active servod instance for key, along
+// with the name of the StartServod operation supervising it, so
+// GracefulShutdown can find and stop it later and StopServod can complete
+// its operation.
+func (s *ServodService) registerActive(key string, req *api.StartServodRequest, opName string) {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	s.active[key] = req
+	s.startOp[key] = opName
+}
+
+// unregisterActive removes key from the active servod registry and returns
+// the name of the StartServod operation that had been supervising it, if
+// any.
+func (s *ServodService) unregisterActive(key string) (string, bool) {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	delete(s.active, key)
+	opName, ok := s.startOp[key]
+	delete(s.startOp, key)
+	return opName, ok
+}
+
+// activeRequests returns a snapshot of the currently active servod
+// instances' StartServodRequests.
+func (s *ServodService) activeRequests() []*api.StartServodRequest {
+	s.activeMu.Lock()
+	defer s.activeMu.Unlock()
+	reqs := make([]*api.StartServodRequest, 0, len(s.active))
+	for _, req := range s.active {
+		reqs = append(reqs, req)
+	}
+	return reqs
+}
+
+// registerOpCancel records cancel as the way to abort opName's in-flight
+// RunCli call, and records servoHostPath as the instance it was issued
+// against.
+func (s *ServodService) registerOpCancel(opName, servoHostPath string, cancel context.CancelFunc) {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	s.opCancel[opName] = cancel
+	s.opServoHostPath[opName] = servoHostPath
+}
+
+// unregisterOpCancel removes opName's cancel func once its RunCli call has
+// returned; opServoHostPath is left in place so ListOperations can still
+// filter on it until the operation itself is deleted or expires (see
+// forgetOpServoHostPath, pruneOpServoHostPath).
+func (s *ServodService) unregisterOpCancel(opName string) {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	delete(s.opCancel, opName)
+}
+
+// lookupOpCancel returns opName's cancel func, if its RunCli call is still
+// in flight.
+func (s *ServodService) lookupOpCancel(opName string) (context.CancelFunc, bool) {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	cancel, ok := s.opCancel[opName]
+	return cancel, ok
+}
+
+// opHasServoHostPath reports whether opName was created against
+// servoHostPath.
+func (s *ServodService) opHasServoHostPath(opName, servoHostPath string) bool {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	return s.opServoHostPath[opName] == servoHostPath
+}
+
+// forgetOpServoHostPath removes opName from opServoHostPath. Callers use
+// this once they know opName's operation is gone for good, so the map
+// doesn't keep growing for every operation ever created.
+func (s *ServodService) forgetOpServoHostPath(opName string) {
+	s.opMu.Lock()
+	defer s.opMu.Unlock()
+	delete(s.opServoHostPath, opName)
+}
+
+// pruneOpServoHostPath forgets every opServoHostPath entry whose operation
+// lro.Manager no longer knows about, e.g. one deleted by Manager's own
+// 30-day expiry sweep rather than through DeleteOperation. It runs
+// periodically from NewServodService so opServoHostPath stays bounded by
+// however many operations are actually live, not by how many have ever
+// been created.
+func (s *ServodService) pruneOpServoHostPath(ctx context.Context) {
+	s.opMu.Lock()
+	names := make([]string, 0, len(s.opServoHostPath))
+	for name := range s.opServoHostPath {
+		names = append(names, name)
+	}
+	s.opMu.Unlock()
+
+	for _, name := range names {
+		if _, err := s.manager.GetOperation(ctx, &longrunning.GetOperationRequest{Name: name}); err != nil {
+			s.forgetOpServoHostPath(name)
+		}
+	}
+}
+
+// GetServodStatus returns the supervision status of the servod instance
+// started by StartServod: its current state, the time of its last probe,
+// and how many times it has been restarted.
+func (s *ServodService) GetServodStatus(ctx context.Context, req *api.GetServodStatusRequest) (*api.GetServodStatusResponse, error) {
+	key := supervisorKey(req.ServoHostPath, req.ServodPort)
+	st, ok := s.supervisor.Status(key)
+	if !ok {
+		return nil, grpcstatus.Errorf(codes.NotFound, "servod %s is not supervised", key)
+	}
+	return &api.GetServodStatusResponse{
+		State:                string(st.State),
+		LastProbeUnixSeconds: st.LastProbeTime.Unix(),
+		RestartCount:         int32(st.RestartCount),
+	}, nil
+}
+
+// supervisorKey returns the key used to look up a servod instance's
+// Supervisor entry.
+func supervisorKey(servoHostPath string, servodPort int32) string {
+	return fmt.Sprintf("%s|%d", servoHostPath, servodPort)
 }
 
 // StopServod stops the servod daemon inside the container and stops the
@@ -97,7 +365,6 @@ func (s *ServodService) StartServod(ctx context.Context, req *api.StartServodReq
 // stops the servod daemon.
 func (s *ServodService) StopServod(ctx context.Context, req *api.StopServodRequest) (*longrunning.Operation, error) {
 	s.logger.Println("Received api.StopServodRequest: ", *req)
-	op := s.manager.NewOperation()
 
 	a := model.CliArgs{
 		ServoHostPath:             req.ServoHostPath,
@@ -105,10 +372,45 @@ func (s *ServodService) StopServod(ctx context.Context, req *api.StopServodReque
 		ServodPort:                req.ServodPort,
 	}
 
-	_, bErr, err := s.RunCli(model.CliStopServod, a, nil, false)
-	if err != nil {
+	// Stop supervising before shutting the instance down, so a probe or
+	// restart triggered mid-shutdown doesn't race with it.
+	key := supervisorKey(req.ServoHostPath, req.ServodPort)
+	s.supervisor.Unwatch(key)
+	startOpName, hadStartOp := s.unregisterActive(key)
+
+	// Complete the StartServod operation left open by superviseServod,
+	// rather than minting an unrelated one, so it doesn't sit open forever.
+	// Fall back to a new operation if that one is gone or already done
+	// (e.g. the instance was already marked servod.StateFatal).
+	opName := ""
+	if hadStartOp {
+		if startOp, err := s.manager.GetOperation(ctx, &longrunning.GetOperationRequest{Name: startOpName}); err == nil && !startOp.Done {
+			opName = startOpName
+		}
+	}
+	if opName == "" {
+		opName = s.manager.NewOperation().Name
+	}
+	op := &longrunning.Operation{Name: opName}
+
+	// opCtx is derived from ctx so StopServod actually honors a deadline the
+	// caller set (e.g. GracefulShutdown bounds this call to drainTimeout);
+	// previously it was rooted in context.Background() and ignored ctx's
+	// deadline entirely, so a stuck StopServod could outlive the shutdown
+	// timeout that was supposed to bound it.
+	opCtx, cancel := context.WithCancel(ctx)
+	s.registerOpCancel(opName, req.ServoHostPath, cancel)
+	defer s.unregisterOpCancel(opName)
+
+	_, bErr, err := s.RunCli(opCtx, model.CliStopServod, a, nil, false)
+	if err != nil && opCtx.Err() != nil {
+		s.logger.Println("Failed to run CLI: ", err)
+		if serr := s.manager.SetError(opName, grpcstatus.New(codes.Canceled, getErrorMessage(bErr, err))); serr != nil {
+			s.logger.Println("Failed to set operation error: ", serr)
+		}
+	} else if err != nil {
 		s.logger.Println("Failed to run CLI: ", err)
-		s.manager.SetResult(op.Name, &api.StopServodResponse{
+		s.manager.SetResult(opName, &api.StopServodResponse{
 			Result: &api.StopServodResponse_Failure_{
 				Failure: &api.StopServodResponse_Failure{
 					ErrorMessage: getErrorMessage(bErr, err),
@@ -116,7 +418,7 @@ func (s *ServodService) StopServod(ctx context.Context, req *api.StopServodReque
 			},
 		})
 	} else {
-		s.manager.SetResult(op.Name, &api.StopServodResponse{
+		s.manager.SetResult(opName, &api.StopServodResponse{
 			Result: &api.StopServodResponse_Success_{},
 		})
 	}
@@ -132,7 +434,11 @@ func (s *ServodService) StopServod(ctx context.Context, req *api.StopServodReque
 // servod_docker_container_name parameter is provided in the request.
 // Otherwise, it executes the command directly inside the host that the servo
 // is physically connected to.
-func (s *ServodService) ExecCmd(ctx context.Context, req *api.ExecCmdRequest) (*api.ExecCmdResponse, error) {
+// Stdout and stderr are streamed back to the caller as ExecCmdResponse
+// chunks as the command produces them, followed by a final chunk carrying
+// only ExitInfo, so long-running commands don't have to buffer their entire
+// output before the caller sees anything.
+func (s *ServodService) ExecCmd(req *api.ExecCmdRequest, stream api.ServodService_ExecCmdServer) error {
 	s.logger.Println("Received api.ExecCmdRequest: ", *req)
 
 	a := model.CliArgs{
@@ -146,15 +452,60 @@ func (s *ServodService) ExecCmd(ctx context.Context, req *api.ExecCmdRequest) (*
 		stdin = bytes.NewReader(req.Stdin)
 	}
 
-	bOut, bErr, err := s.RunCli(model.CliExecCmd, a, stdin, false)
+	// stdout and stderr are written from separate copy goroutines (one per
+	// os/exec or SSH session stream), so sendMu serializes their
+	// stream.Send calls: concurrent, unsynchronized Sends on one gRPC
+	// stream are unsafe.
+	var sendMu sync.Mutex
+	stdout := &execCmdStreamWriter{stream: stream, sink: execCmdStdout, sendMu: &sendMu}
+	stderr := &execCmdStreamWriter{stream: stream, sink: execCmdStderr, sendMu: &sendMu}
+
+	err := s.RunCliStreaming(stream.Context(), model.CliExecCmd, a, stdin, stdout, stderr)
 	if err != nil {
 		s.logger.Println("Failed to run CLI: ", err)
 	}
-	return &api.ExecCmdResponse{
-		ExitInfo: getExitInfo(err),
-		Stdout:   bOut.Bytes(),
-		Stderr:   bErr.Bytes(),
-	}, err
+
+	if serr := stream.Send(&api.ExecCmdResponse{ExitInfo: getExitInfo(err)}); serr != nil {
+		return serr
+	}
+	return err
+}
+
+// execCmdSink identifies which field of an ExecCmdResponse an
+// execCmdStreamWriter should populate with the bytes it's given.
+type execCmdSink int
+
+const (
+	execCmdStdout execCmdSink = iota
+	execCmdStderr
+)
+
+// execCmdStreamWriter is an io.Writer adapter that turns each Write into an
+// ExecCmdResponse chunk sent on stream, so ExecCmd can be handed ordinary
+// stdout/stderr sinks while still streaming to the RPC caller. The stdout
+// and stderr writers for a single ExecCmd call share sendMu, since
+// os/exec and the SSH session both copy into them from separate
+// goroutines and gRPC streams don't allow concurrent Sends.
+type execCmdStreamWriter struct {
+	stream api.ServodService_ExecCmdServer
+	sink   execCmdSink
+	sendMu *sync.Mutex
+}
+
+func (w *execCmdStreamWriter) Write(p []byte) (int, error) {
+	b := append([]byte(nil), p...)
+	resp := &api.ExecCmdResponse{}
+	if w.sink == execCmdStdout {
+		resp.Stdout = b
+	} else {
+		resp.Stderr = b
+	}
+	w.sendMu.Lock()
+	defer w.sendMu.Unlock()
+	if err := w.stream.Send(resp); err != nil {
+		return 0, err
+	}
+	return len(p), nil
 }
 
 // CallServod runs a servod command through an XML-RPC call.
@@ -174,33 +525,37 @@ func (s *ServodService) CallServod(ctx context.Context, req *api.CallServodReque
 			return []string{}, nil
 		})
 	if err != nil {
-		return &api.CallServodResponse{
-			Result: &api.CallServodResponse_Failure_{
-				Failure: &api.CallServodResponse_Failure{
-					ErrorMessage: err.Error(),
-				},
-			},
-		}, err
+		return callServodFailure(err), err
 	}
 
 	val, err := sd.Call(ctx, s.sshPool, strings.ToLower(req.Method.String()), req.Args)
 	if err != nil {
-		return &api.CallServodResponse{
-			Result: &api.CallServodResponse_Failure_{
-				Failure: &api.CallServodResponse_Failure{
-					ErrorMessage: err.Error(),
-				},
-			},
-		}, err
+		return callServodFailure(err), err
 	}
 
+	return callServodSuccess(val), nil
+}
+
+// callServodSuccess wraps val as a successful CallServodResponse.
+func callServodSuccess(val *xmlrpc_value.Value) *api.CallServodResponse {
 	return &api.CallServodResponse{
 		Result: &api.CallServodResponse_Success_{
 			Success: &api.CallServodResponse_Success{
 				Result: val,
 			},
 		},
-	}, nil
+	}
+}
+
+// callServodFailure wraps err as a failed CallServodResponse.
+func callServodFailure(err error) *api.CallServodResponse {
+	return &api.CallServodResponse{
+		Result: &api.CallServodResponse_Failure_{
+			Failure: &api.CallServodResponse_Failure{
+				ErrorMessage: err.Error(),
+			},
+		},
+	}
 }
 
 // getErrorMessage returns either Stderr output or error message
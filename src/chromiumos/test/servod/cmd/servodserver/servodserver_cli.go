@@ -7,6 +7,7 @@ package servodserver
 import (
 	"bytes"
 	"chromiumos/test/servod/cmd/model"
+	"context"
 	"fmt"
 	"io"
 	"strings"
@@ -14,57 +15,61 @@ import (
 	"go.chromium.org/luci/common/errors"
 )
 
-// RunCli runs servod service as execution by CLI.
-func (s *ServodService) RunCli(cs model.CliSubcommand, a model.CliArgs, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+// RunCli runs servod service as execution by CLI, through whichever
+// ServodRuntime applies to a (see newServodRuntime). If ctx is done before
+// the command exits, the command is aborted and ctx.Err() is returned;
+// Dockerized runtimes don't support this yet and ignore ctx.
+func (s *ServodService) RunCli(ctx context.Context, cs model.CliSubcommand, a model.CliArgs, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 	s.logger.Println("Start running the servod service CLI.")
-
-	var bOut bytes.Buffer
-	var bErr bytes.Buffer
-	var err error
-
-	command := ""
-	switch cs {
-	case model.CliStartServod:
-		command, err = s.getStartServodCommand(a)
-	case model.CliStopServod:
-		command = s.getStopServodCommand(a)
-	case model.CliExecCmd:
-		command = s.getExecCmdCommand(a)
-	case model.CliCallServod:
-		command = s.getCallServodCommand(a)
+	bOut, bErr, err := s.newServodRuntime(a).Run(ctx, cs, a, stdin, routeToStd)
+	if err != nil {
+		return bOut, bErr, err
 	}
+	s.logger.Println("Finished running the servod service CLI successfully!")
+	return bOut, bErr, err
+}
 
-	if command != "" {
-		s.logger.Printf("Execute command: %s", command)
-		bOut, bErr, err = s.commandexecutor.Run(a.ServoHostPath, command, stdin, routeToStd)
-		if err != nil {
-			return bOut, bErr, err
-		}
-		s.logger.Println("Finished running the servod service CLI successfully!")
+// RunCliStreaming runs servod service as execution by CLI the same way as
+// RunCli, except stdout and stderr are written to the given sinks as bytes
+// become available instead of being buffered and returned once the command
+// exits.
+func (s *ServodService) RunCliStreaming(ctx context.Context, cs model.CliSubcommand, a model.CliArgs, stdin io.Reader, stdout, stderr io.Writer) error {
+	s.logger.Println("Start running the servod service CLI.")
+	if err := s.newServodRuntime(a).RunStreaming(ctx, cs, a, stdin, stdout, stderr); err != nil {
+		return err
 	}
-
-	return bOut, bErr, err
+	s.logger.Println("Finished running the servod service CLI successfully!")
+	return nil
 }
 
-// getStartServodCommand returns either a "docker run" command when
-// ServodDockerImagePath is specified or a "start servod" command
-// when ServodDockerImagePath is empty.
-func (s *ServodService) getStartServodCommand(a model.CliArgs) (string, error) {
+// validateStartServodArgs checks the input parameters required to start a
+// servod instance, Docker or not.
+func validateStartServodArgs(a model.CliArgs) error {
 	if a.Board == "" {
-		return "", errors.Reason("Board not specified").Err()
+		return errors.Reason("Board not specified").Err()
 	}
 	if a.Model == "" {
-		return "", errors.Reason("Model not specified").Err()
+		return errors.Reason("Model not specified").Err()
 	}
 	if a.SerialName == "" {
-		return "", errors.Reason("SerialName not specified").Err()
+		return errors.Reason("SerialName not specified").Err()
+	}
+	if a.ServodDockerImagePath != "" && a.ServodDockerContainerName == "" {
+		return errors.Reason("ServodDockerContainerName not specified").Err()
+	}
+	return nil
+}
+
+// getStartServodCommand returns either a "docker run" command when
+// ServodDockerImagePath is specified or a "start servod" command
+// when ServodDockerImagePath is empty.
+func getStartServodCommand(a model.CliArgs) (string, error) {
+	if err := validateStartServodArgs(a); err != nil {
+		return "", err
 	}
 
 	command := ""
 	if a.ServodDockerImagePath != "" {
-		if a.ServodDockerContainerName == "" {
-			return "", errors.Reason("ServodDockerContainerName not specified").Err()
-		}
 		command = fmt.Sprintf("docker run -d --network host --name %s %s --cap-add=NET_ADMIN --volume=/dev:/dev --privileged %s /start_servod.sh",
 			a.ServodDockerContainerName, getStartServodEnv(a, "--env "), a.ServodDockerImagePath)
 	} else {
@@ -98,7 +103,7 @@ func getStartServodEnv(a model.CliArgs, envPrefix string) string {
 // getStopServodCommand returns either a "docker stop" command when
 // ServodDockerContainerName is specified or a "stop servod" command
 // when ServodDockerContainerName is empty.
-func (s *ServodService) getStopServodCommand(a model.CliArgs) string {
+func getStopServodCommand(a model.CliArgs) string {
 	command := ""
 	if a.ServodDockerContainerName != "" {
 		command = fmt.Sprintf("docker exec -d %s /stop_servod.sh && docker stop %s",
@@ -112,7 +117,7 @@ func (s *ServodService) getStopServodCommand(a model.CliArgs) string {
 // getExecCmdCommand returns either a "docker exec" command when
 // ServodDockerContainerName is specified or the command provided
 // when ServodDockerContainerName is empty.
-func (s *ServodService) getExecCmdCommand(a model.CliArgs) string {
+func getExecCmdCommand(a model.CliArgs) string {
 	if a.ServodDockerContainerName != "" {
 		return fmt.Sprintf("docker exec -d %s '%s'",
 			a.ServodDockerContainerName, a.Command)
@@ -121,19 +126,23 @@ func (s *ServodService) getExecCmdCommand(a model.CliArgs) string {
 	}
 }
 
-// getCallServodCommand returns either a "docker exec" command when
-// ServodDockerContainerName is specified or a "dut-control" command
-// when ServodDockerContainerName is empty.
-func (s *ServodService) getCallServodCommand(a model.CliArgs) string {
-	command := ""
-	// Generate a "dut-control" command based on the method and args provided.
+// dutControlCommand returns the "dut-control" command for the method and
+// args provided, without any Docker wrapping.
+func dutControlCommand(a model.CliArgs) string {
 	switch strings.ToLower(a.Method) {
 	case "doc":
-		command = fmt.Sprintf("dut-control -p %d -i %s", a.ServodPort, a.Args)
+		return fmt.Sprintf("dut-control -p %d -i %s", a.ServodPort, a.Args)
 	case "get", "set":
-		command = fmt.Sprintf("dut-control -p %d %s", a.ServodPort, a.Args)
+		return fmt.Sprintf("dut-control -p %d %s", a.ServodPort, a.Args)
 	}
+	return ""
+}
 
+// getCallServodCommand returns either a "docker exec" command when
+// ServodDockerContainerName is specified or a "dut-control" command
+// when ServodDockerContainerName is empty.
+func getCallServodCommand(a model.CliArgs) string {
+	command := dutControlCommand(a)
 	if a.ServodDockerContainerName != "" {
 		return fmt.Sprintf("docker exec -d %s '%s'",
 			a.ServodDockerContainerName, command)
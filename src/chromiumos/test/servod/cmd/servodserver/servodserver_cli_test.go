@@ -21,8 +21,8 @@ func TestServodCLI_StartServodSuccess(t *testing.T) {
 
 	expectedCmd := "start servod PORT=0 BOARD=board MODEL=model SERIAL=serialname"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -46,7 +46,7 @@ func TestServodCLI_StartServodSuccess(t *testing.T) {
 		SerialName:    "serialname",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliStartServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliStartServod, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -69,8 +69,8 @@ func TestServodCLI_StartServodAllParams(t *testing.T) {
 
 	expectedCmd := "start servod PORT=0 BOARD=board MODEL=model SERIAL=serialname DUAL_V4=allowDualV4 CONFIG=config DEBUG=debug REC_MODE=recoveryMode"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -98,7 +98,7 @@ func TestServodCLI_StartServodAllParams(t *testing.T) {
 		RecoveryMode:  "recoveryMode",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliStartServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliStartServod, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -121,8 +121,8 @@ func TestServodCLI_StartServodDockerizedSuccess(t *testing.T) {
 
 	expectedCmd := "docker run -d --network host --name servodDockerContainerName --env PORT=0 --env BOARD=board --env MODEL=model --env SERIAL=serialname --cap-add=NET_ADMIN --volume=/dev:/dev --privileged servodDockerImagePath /start_servod.sh"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -148,7 +148,7 @@ func TestServodCLI_StartServodDockerizedSuccess(t *testing.T) {
 		SerialName:                "serialname",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliStartServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliStartServod, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -186,7 +186,7 @@ func TestServodCLI_StartServodDockerizedWithoutContainerName(t *testing.T) {
 		SerialName:            "serialname",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliStartServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliStartServod, a, nil, false)
 	if err == nil {
 		t.Fatalf("Should have failed at api.ExecCmd.")
 	}
@@ -226,7 +226,7 @@ func TestServodCLI_StartServodWithoutBoard(t *testing.T) {
 		SerialName:    "serialname",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliStartServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliStartServod, a, nil, false)
 	if err == nil {
 		t.Fatalf("Should have failed at api.ExecCmd.")
 	}
@@ -266,7 +266,7 @@ func TestServodCLI_StartServodWithoutModel(t *testing.T) {
 		SerialName:    "serialname",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliStartServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliStartServod, a, nil, false)
 	if err == nil {
 		t.Fatalf("Should have failed at api.ExecCmd.")
 	}
@@ -306,7 +306,7 @@ func TestServodCLI_StartServodWithoutSerialName(t *testing.T) {
 		Model:         "model",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliStartServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliStartServod, a, nil, false)
 	if err == nil {
 		t.Fatalf("Should have failed at api.ExecCmd.")
 	}
@@ -333,8 +333,8 @@ func TestServodCLI_StopServodSuccess(t *testing.T) {
 
 	expectedCmd := "stop servod PORT=0"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -355,7 +355,7 @@ func TestServodCLI_StopServodSuccess(t *testing.T) {
 		ServodPort:    0,
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliStopServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliStopServod, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -378,8 +378,8 @@ func TestServodCLI_StopServodDockerizedSuccess(t *testing.T) {
 
 	expectedCmd := "docker exec -d servodDockerContainerName /stop_servod.sh && docker stop servodDockerContainerName"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -401,7 +401,7 @@ func TestServodCLI_StopServodDockerizedSuccess(t *testing.T) {
 		ServodPort:                0,
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliStopServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliStopServod, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -424,8 +424,8 @@ func TestServodCLI_ExecCmdSuccess(t *testing.T) {
 
 	expectedCmd := "command"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -446,7 +446,7 @@ func TestServodCLI_ExecCmdSuccess(t *testing.T) {
 		Command:       "command",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliExecCmd, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliExecCmd, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -469,8 +469,8 @@ func TestServodCLI_ExecCmdDockerizedSuccess(t *testing.T) {
 
 	expectedCmd := "docker exec -d servodDockerContainerName 'command'"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -492,7 +492,7 @@ func TestServodCLI_ExecCmdDockerizedSuccess(t *testing.T) {
 		Command:                   "command",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliExecCmd, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliExecCmd, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -515,8 +515,8 @@ func TestServodCLI_CallServodDocSuccess(t *testing.T) {
 
 	expectedCmd := "dut-control -p 0 -i args"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -539,7 +539,7 @@ func TestServodCLI_CallServodDocSuccess(t *testing.T) {
 		Args:          "args",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliCallServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliCallServod, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -562,8 +562,8 @@ func TestServodCLI_CallServodDockerizedDocSuccess(t *testing.T) {
 
 	expectedCmd := "docker exec -d servodDockerContainerName 'dut-control -p 0 -i args'"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -587,7 +587,7 @@ func TestServodCLI_CallServodDockerizedDocSuccess(t *testing.T) {
 		Args:                      "args",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliCallServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliCallServod, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -610,8 +610,8 @@ func TestServodCLI_CallServodGetSuccess(t *testing.T) {
 
 	expectedCmd := "dut-control -p 0 args"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -634,7 +634,7 @@ func TestServodCLI_CallServodGetSuccess(t *testing.T) {
 		Args:          "args",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliCallServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliCallServod, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -657,8 +657,8 @@ func TestServodCLI_CallServodDockerizedGetSuccess(t *testing.T) {
 
 	expectedCmd := "docker exec -d servodDockerContainerName 'dut-control -p 0 args'"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -682,7 +682,7 @@ func TestServodCLI_CallServodDockerizedGetSuccess(t *testing.T) {
 		Args:                      "args",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliCallServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliCallServod, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -705,8 +705,8 @@ func TestServodCLI_CallServodSetSuccess(t *testing.T) {
 
 	expectedCmd := "dut-control -p 0 args"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -729,7 +729,7 @@ func TestServodCLI_CallServodSetSuccess(t *testing.T) {
 		Args:          "args",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliCallServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliCallServod, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -752,8 +752,8 @@ func TestServodCLI_CallServodDockerizedSetSuccess(t *testing.T) {
 
 	expectedCmd := "docker exec -d servodDockerContainerName 'dut-control -p 0 args'"
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq(expectedCmd), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -777,7 +777,7 @@ func TestServodCLI_CallServodDockerizedSetSuccess(t *testing.T) {
 		Args:                      "args",
 	}
 
-	bOut, bErr, err := srv.RunCli(model.CliCallServod, a, nil, false)
+	bOut, bErr, err := srv.RunCli(ctx, model.CliCallServod, a, nil, false)
 	if err != nil {
 		t.Fatalf("Failed at api.RunCli: %v", err)
 	}
@@ -7,29 +7,49 @@ package servodserver
 import (
 	"fmt"
 	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"go.chromium.org/chromiumos/config/go/longrunning"
 	"go.chromium.org/chromiumos/config/go/test/api"
 	"go.chromium.org/luci/common/errors"
 	"google.golang.org/grpc"
-
-	"chromiumos/lro"
 )
 
-// StartServer starts servod server on requested port
-func (s *ServodService) StartServer(port int32) error {
+// StartServer starts servod server on requested port. On SIGINT, SIGTERM, or
+// SIGHUP, it drains the server through GracefulShutdown, bounding each
+// instance's shutdown to drainTimeout, before returning.
+func (s *ServodService) StartServer(port int32, drainTimeout time.Duration) error {
 	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
 	if err != nil {
 		return errors.Annotate(err, "Start servod server: failed to create listener at %d", port).Err()
 	}
 
-	s.manager = lro.New()
-	defer s.manager.Close()
 	server := grpc.NewServer()
 
 	api.RegisterServodServiceServer(server, s)
-	longrunning.RegisterOperationsServer(server, s.manager)
+	// Register s, not s.manager, so CancelOperation can actually abort an
+	// in-flight StartServod/StopServod RunCli call (see operations.go);
+	// Get/Delete/Wait/ListOperations are otherwise thin delegations to
+	// s.manager.
+	longrunning.RegisterOperationsServer(server, s)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	done := make(chan struct{})
+	go s.GracefulShutdown(sigCh, server, drainTimeout, done)
 
 	s.logger.Println("Servod server is listening to request at ", l.Addr().String())
-	return server.Serve(l)
+	err = server.Serve(l)
+	signal.Stop(sigCh)
+	if err != nil && err != grpc.ErrServerStopped {
+		return err
+	}
+	// server.Serve only returns this way once GracefulShutdown has called
+	// server.Stop(); wait for it to finish draining active servod instances
+	// too, so the caller's destructor doesn't run until that's done.
+	<-done
+	return nil
 }
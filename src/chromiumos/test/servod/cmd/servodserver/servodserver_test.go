@@ -6,14 +6,34 @@ import (
 	"context"
 	"io"
 	"log"
+	"sync"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"go.chromium.org/chromiumos/config/go/longrunning"
 	"go.chromium.org/chromiumos/config/go/test/api"
 	"go.chromium.org/luci/common/errors"
+	"google.golang.org/grpc"
 )
 
+// fakeExecCmdServer is a minimal api.ServodService_ExecCmdServer double that
+// records every ExecCmdResponse sent to it, in order, so tests can assert on
+// streamed delivery of partial output. Send is mutex-guarded so the double
+// itself stays race-free when exercised by concurrent stdout/stderr writers.
+type fakeExecCmdServer struct {
+	grpc.ServerStream
+
+	mu   sync.Mutex
+	sent []*api.ExecCmdResponse
+}
+
+func (f *fakeExecCmdServer) Send(resp *api.ExecCmdResponse) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, resp)
+	return nil
+}
+
 // Tests that servod starts successfully.
 func TestServodServer_StartServodSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -21,8 +41,8 @@ func TestServodServer_StartServodSuccess(t *testing.T) {
 
 	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -62,8 +82,8 @@ func TestServodServer_StartServodFailure(t *testing.T) {
 
 	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("not success!"))
 			bErr.Write([]byte("failed!"))
@@ -107,8 +127,8 @@ func TestServodServer_StopServodSuccess(t *testing.T) {
 
 	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -145,8 +165,8 @@ func TestServodServer_StopServodFailure(t *testing.T) {
 
 	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("not success!"))
 			bErr.Write([]byte("failed!"))
@@ -180,19 +200,33 @@ func TestServodServer_StopServodFailure(t *testing.T) {
 	}
 }
 
-// Tests that a command executes successfully.
+// execCmdOutput concatenates the Stdout and Stderr bytes of every chunk sent
+// to a fakeExecCmdServer, and returns the ExitInfo carried by the final one.
+func execCmdOutput(sent []*api.ExecCmdResponse) (stdout, stderr string, exitInfo *api.ExecCmdResponse_ExitInfo) {
+	var bOut, bErr bytes.Buffer
+	for _, resp := range sent {
+		bOut.Write(resp.Stdout)
+		bErr.Write(resp.Stderr)
+		if resp.ExitInfo != nil {
+			exitInfo = resp.ExitInfo
+		}
+	}
+	return bOut.String(), bErr.String(), exitInfo
+}
+
+// Tests that a command executes successfully, streaming stdout/stderr as
+// separate chunks ahead of a final chunk carrying ExitInfo.
 func TestServodServer_ExecCmdSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq("command arg1 arg2"), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
-			var bOut, bErr bytes.Buffer
-			bOut.Write([]byte("success!"))
-			bErr.Write([]byte("not failed!"))
-			return bOut, bErr, nil
+	mce.EXPECT().RunStreaming(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq("command arg1 arg2"), gomock.Eq(nil), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+			stdout.Write([]byte("success!"))
+			stderr.Write([]byte("not failed!"))
+			return nil
 		},
 	)
 
@@ -204,32 +238,90 @@ func TestServodServer_ExecCmdSuccess(t *testing.T) {
 		t.Fatalf("Failed to create new ServodService: %v", err)
 	}
 
-	resp, err := srv.ExecCmd(ctx, &api.ExecCmdRequest{
+	stream := &fakeExecCmdServer{}
+	err = srv.ExecCmd(&api.ExecCmdRequest{
 		ServoHostPath: "servoHostPath",
 		Command:       "command arg1 arg2",
-	})
+	}, stream)
 	if err != nil {
 		t.Fatalf("Failed at api.ExecCmd: %v", err)
 	}
 
-	if string(resp.Stderr) != "not failed!" {
-		t.Fatalf("Expecting Stderr to be \"not failed!\", instead got %v", string(resp.Stderr))
+	stdout, stderr, exitInfo := execCmdOutput(stream.sent)
+	if stderr != "not failed!" {
+		t.Fatalf("Expecting Stderr to be \"not failed!\", instead got %v", stderr)
 	}
 
-	if string(resp.Stdout) != "success!" {
-		t.Fatalf("Expecting Stdout to be \"success!\", instead got %v", string(resp.Stdout))
+	if stdout != "success!" {
+		t.Fatalf("Expecting Stdout to be \"success!\", instead got %v", stdout)
 	}
 
-	if resp.ExitInfo.Signaled {
+	if exitInfo.Signaled {
 		t.Fatalf("ExitInfo.Signaled should not be set!")
 	}
 
-	if !resp.ExitInfo.Started {
+	if !exitInfo.Started {
 		t.Fatalf("ExitInfo.Started should be set!")
 	}
 
-	if resp.ExitInfo.Status != 0 {
-		t.Fatalf("Expecting ExitInfo.Status to be 0, instead got: %v", resp.ExitInfo.Status)
+	if exitInfo.Status != 0 {
+		t.Fatalf("Expecting ExitInfo.Status to be 0, instead got: %v", exitInfo.Status)
+	}
+
+	if stream.sent[len(stream.sent)-1].ExitInfo == nil {
+		t.Fatalf("Expecting the final chunk sent to carry ExitInfo")
+	}
+}
+
+// Tests that concurrent writes to stdout and stderr, as os/exec's copy
+// goroutines would produce for a real command, don't race on the shared
+// gRPC stream. Run with -race to catch a regression.
+func TestServodServer_ExecCmdConcurrentStdoutStderrIsRaceFree(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
+
+	mce.EXPECT().RunStreaming(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq("command"), gomock.Eq(nil), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					stdout.Write([]byte("o"))
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					stderr.Write([]byte("e"))
+				}
+			}()
+			wg.Wait()
+			return nil
+		},
+	)
+
+	ctx := context.Background()
+	var logBuf bytes.Buffer
+	srv, destructor, err := NewServodService(ctx, log.New(&logBuf, "", log.LstdFlags|log.LUTC), mce)
+	defer destructor()
+	if err != nil {
+		t.Fatalf("Failed to create new ServodService: %v", err)
+	}
+
+	stream := &fakeExecCmdServer{}
+	if err := srv.ExecCmd(&api.ExecCmdRequest{ServoHostPath: "servoHostPath", Command: "command"}, stream); err != nil {
+		t.Fatalf("Failed at api.ExecCmd: %v", err)
+	}
+
+	stdout, stderr, _ := execCmdOutput(stream.sent)
+	if len(stdout) != 50 {
+		t.Errorf("len(stdout) = %d, want 50", len(stdout))
+	}
+	if len(stderr) != 50 {
+		t.Errorf("len(stderr) = %d, want 50", len(stderr))
 	}
 }
 
@@ -243,12 +335,11 @@ func TestServodServer_ExecCmdWithStdinSuccess(t *testing.T) {
 	stdin := []byte("stdin")
 	var expectedStdin io.Reader = bytes.NewReader(stdin)
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq("command arg1 arg2"), gomock.Eq(expectedStdin), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
-			var bOut, bErr bytes.Buffer
-			bOut.Write([]byte("success!"))
-			bErr.Write([]byte("not failed!"))
-			return bOut, bErr, nil
+	mce.EXPECT().RunStreaming(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq("command arg1 arg2"), gomock.Eq(expectedStdin), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+			stdout.Write([]byte("success!"))
+			stderr.Write([]byte("not failed!"))
+			return nil
 		},
 	)
 
@@ -260,33 +351,35 @@ func TestServodServer_ExecCmdWithStdinSuccess(t *testing.T) {
 		t.Fatalf("Failed to create new ServodService: %v", err)
 	}
 
-	resp, err := srv.ExecCmd(ctx, &api.ExecCmdRequest{
+	stream := &fakeExecCmdServer{}
+	err = srv.ExecCmd(&api.ExecCmdRequest{
 		ServoHostPath: "servoHostPath",
 		Command:       "command arg1 arg2",
 		Stdin:         stdin,
-	})
+	}, stream)
 	if err != nil {
 		t.Fatalf("Failed at api.ExecCmd: %v", err)
 	}
 
-	if string(resp.Stderr) != "not failed!" {
-		t.Fatalf("Expecting Stderr to be \"not failed!\", instead got %v", string(resp.Stderr))
+	stdout, stderr, exitInfo := execCmdOutput(stream.sent)
+	if stderr != "not failed!" {
+		t.Fatalf("Expecting Stderr to be \"not failed!\", instead got %v", stderr)
 	}
 
-	if string(resp.Stdout) != "success!" {
-		t.Fatalf("Expecting Stdout to be \"success!\", instead got %v", string(resp.Stdout))
+	if stdout != "success!" {
+		t.Fatalf("Expecting Stdout to be \"success!\", instead got %v", stdout)
 	}
 
-	if resp.ExitInfo.Signaled {
+	if exitInfo.Signaled {
 		t.Fatalf("ExitInfo.Signaled should not be set!")
 	}
 
-	if !resp.ExitInfo.Started {
+	if !exitInfo.Started {
 		t.Fatalf("ExitInfo.Started should be set!")
 	}
 
-	if resp.ExitInfo.Status != 0 {
-		t.Fatalf("Expecting ExitInfo.Status to be 0, instead got: %v", resp.ExitInfo.Status)
+	if exitInfo.Status != 0 {
+		t.Fatalf("Expecting ExitInfo.Status to be 0, instead got: %v", exitInfo.Status)
 	}
 }
 
@@ -297,12 +390,11 @@ func TestServodServer_ExecCmdFailure(t *testing.T) {
 
 	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Eq("command arg1 arg2"), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
-			var bOut, bErr bytes.Buffer
-			bOut.Write([]byte("not success!"))
-			bErr.Write([]byte("failed!"))
-			return bOut, bErr, errors.Reason("error message").Err()
+	mce.EXPECT().RunStreaming(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Eq("command arg1 arg2"), gomock.Eq(nil), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, stdout, stderr io.Writer) error {
+			stdout.Write([]byte("not success!"))
+			stderr.Write([]byte("failed!"))
+			return errors.Reason("error message").Err()
 		},
 	)
 
@@ -314,36 +406,38 @@ func TestServodServer_ExecCmdFailure(t *testing.T) {
 		t.Fatalf("Failed to create new ServodService: %v", err)
 	}
 
-	resp, err := srv.ExecCmd(ctx, &api.ExecCmdRequest{
+	stream := &fakeExecCmdServer{}
+	err = srv.ExecCmd(&api.ExecCmdRequest{
 		ServoHostPath: "servoHostPath",
 		Command:       "command arg1 arg2",
-	})
+	}, stream)
 	if err == nil {
 		t.Fatalf("Should have failed at api.ExecCmd.")
 	}
 
-	if string(resp.Stderr) != "failed!" {
-		t.Fatalf("Expecting Stderr to be \"failed!\", instead got %v", string(resp.Stderr))
+	stdout, stderr, exitInfo := execCmdOutput(stream.sent)
+	if stderr != "failed!" {
+		t.Fatalf("Expecting Stderr to be \"failed!\", instead got %v", stderr)
 	}
 
-	if string(resp.Stdout) != "not success!" {
-		t.Fatalf("Expecting Stdout to be \"not success!\", instead got %v", string(resp.Stdout))
+	if stdout != "not success!" {
+		t.Fatalf("Expecting Stdout to be \"not success!\", instead got %v", stdout)
 	}
 
-	if resp.ExitInfo.ErrorMessage != "error message" {
-		t.Fatalf("Expecting ExitInfo.ErrorMessage to be \"error message\", instead got %v", resp.ExitInfo.ErrorMessage)
+	if exitInfo.ErrorMessage != "error message" {
+		t.Fatalf("Expecting ExitInfo.ErrorMessage to be \"error message\", instead got %v", exitInfo.ErrorMessage)
 	}
 
-	if resp.ExitInfo.Signaled {
+	if exitInfo.Signaled {
 		t.Fatalf("ExitInfo.Signaled should not be set!")
 	}
 
-	if resp.ExitInfo.Started {
+	if exitInfo.Started {
 		t.Fatalf("ExitInfo.Started should not be set!")
 	}
 
-	if resp.ExitInfo.Status == 0 {
-		t.Fatalf("Expecting ExitInfo.Status to be not 0, instead got: %v", resp.ExitInfo.Status)
+	if exitInfo.Status == 0 {
+		t.Fatalf("Expecting ExitInfo.Status to be not 0, instead got: %v", exitInfo.Status)
 	}
 }
 
@@ -354,8 +448,8 @@ func TestServodServer_CallServodSuccess(t *testing.T) {
 
 	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("success!"))
 			bErr.Write([]byte("not failed!"))
@@ -397,8 +491,8 @@ func TestServodServer_CallServodFailure(t *testing.T) {
 
 	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
 
-	mce.EXPECT().Run(gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
-		func(addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath"), gomock.Any(), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr string, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
 			var bOut, bErr bytes.Buffer
 			bOut.Write([]byte("not success!"))
 			bErr.Write([]byte("failed!"))
@@ -0,0 +1,53 @@
+// Copyright 2021 The Chromium OS Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package servodserver
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"go.chromium.org/chromiumos/config/go/test/api"
+)
+
+// stoppableServer is the subset of grpc.Server that GracefulShutdown needs.
+// grpc.Server.Stop, unlike GracefulStop, both stops the listener immediately
+// and cancels the context of every in-flight RPC, which is exactly what's
+// needed to satisfy (1) and (2) below in a single call.
+type stoppableServer interface {
+	Stop()
+}
+
+// GracefulShutdown waits for a signal on sigCh, then drains the server: it
+// (1) stops accepting new RPCs and (2) cancels in-flight operations by
+// stopping server, then (3) stops every still-active servod instance,
+// bounding each StopServod call to drainTimeout so one stuck instance can't
+// block the others. done is closed once the drain is complete, so a caller
+// blocked on server.Serve knows not to return - and so destructor, e.g. a
+// deferred call in main, doesn't run - until every instance has been asked
+// to stop.
+func (s *ServodService) GracefulShutdown(sigCh <-chan os.Signal, server stoppableServer, drainTimeout time.Duration, done chan<- struct{}) {
+	<-sigCh
+	s.logger.Println("Received shutdown signal, draining servod server.")
+
+	server.Stop()
+
+	for _, req := range s.activeRequests() {
+		ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		_, err := s.StopServod(ctx, &api.StopServodRequest{
+			ServoHostPath:             req.ServoHostPath,
+			ServodDockerContainerName: req.ServodDockerContainerName,
+			ServodPort:                req.ServodPort,
+		})
+		cancel()
+		if err != nil {
+			s.logger.Println("Graceful shutdown: failed to stop servod ",
+				supervisorKey(req.ServoHostPath, req.ServodPort), ": ", err)
+		}
+	}
+
+	s.logger.Println("Servod server drained, shutting down.")
+	close(done)
+}
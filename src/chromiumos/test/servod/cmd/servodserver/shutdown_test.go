@@ -0,0 +1,103 @@
+// Copyright 2021 The Chromium OS Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package servodserver
+
+import (
+	"bytes"
+	"chromiumos/test/servod/cmd/mock_commandexecutor"
+	"context"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"go.chromium.org/chromiumos/config/go/test/api"
+)
+
+// fakeStoppableServer is a stoppableServer double that records whether Stop
+// was called, so tests can assert GracefulShutdown stops the server before
+// draining active servod instances.
+type fakeStoppableServer struct {
+	mu      sync.Mutex
+	stopped bool
+}
+
+func (f *fakeStoppableServer) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = true
+}
+
+// Tests that GracefulShutdown stops the server and issues StopServod's
+// underlying stop command against every active servod instance before
+// returning.
+func TestServodServer_GracefulShutdownStopsAllActive(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mce := mock_commandexecutor.NewMockCommandExecutorInterface(ctrl)
+
+	var mu sync.Mutex
+	stopped := map[string]bool{}
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath1"), gomock.Eq("stop servod PORT=1111"), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+			mu.Lock()
+			stopped[addr] = true
+			mu.Unlock()
+			return bytes.Buffer{}, bytes.Buffer{}, nil
+		},
+	)
+	mce.EXPECT().Run(gomock.Any(), gomock.Eq("servoHostPath2"), gomock.Eq("stop servod PORT=2222"), gomock.Eq(nil), gomock.Eq(false)).DoAndReturn(
+		func(ctx context.Context, addr, command string, stdin io.Reader, routeToStd bool) (bytes.Buffer, bytes.Buffer, error) {
+			mu.Lock()
+			stopped[addr] = true
+			mu.Unlock()
+			return bytes.Buffer{}, bytes.Buffer{}, nil
+		},
+	)
+
+	ctx := context.Background()
+	var logBuf bytes.Buffer
+	srv, destructor, err := NewServodService(ctx, log.New(&logBuf, "", log.LstdFlags|log.LUTC), mce)
+	defer destructor()
+	if err != nil {
+		t.Fatalf("Failed to create new ServodService: %v", err)
+	}
+
+	srv.registerActive(supervisorKey("servoHostPath1", 1111), &api.StartServodRequest{
+		ServoHostPath: "servoHostPath1",
+		ServodPort:    1111,
+	}, srv.manager.NewOperation().Name)
+	srv.registerActive(supervisorKey("servoHostPath2", 2222), &api.StartServodRequest{
+		ServoHostPath: "servoHostPath2",
+		ServodPort:    2222,
+	}, srv.manager.NewOperation().Name)
+
+	sigCh := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	server := &fakeStoppableServer{}
+
+	go srv.GracefulShutdown(sigCh, server, time.Second, done)
+	sigCh <- os.Interrupt
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("GracefulShutdown did not finish draining in time")
+	}
+
+	if !server.stopped {
+		t.Fatalf("Expecting server.Stop() to have been called")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if !stopped["servoHostPath1"] || !stopped["servoHostPath2"] {
+		t.Fatalf("Expecting every active servod instance to be stopped, instead got %v", stopped)
+	}
+}
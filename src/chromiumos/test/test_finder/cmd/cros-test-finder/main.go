@@ -123,17 +123,28 @@ var defaultPort = 8010
 
 type args struct {
 	// Common input params.
-	logPath     string
-	inputPath   string
-	output      string
-	metadataDir string
-	version     bool
+	logPath        string
+	inputPath      string
+	output         string
+	metadataDir    string
+	exclusionsPath string
+	version        bool
 
 	// Server mode params
 	port int
 }
 
-func innerMain(logger *log.Logger, req *api.CrosTestFinderRequest, metadataDir string) (*api.CrosTestFinderResponse, error) {
+// loadExclusions reads exclusionsPath, if set, into a list of
+// finder.Exclusion. An unset exclusionsPath is not an error: it just means
+// no exclusions were configured for this run.
+func loadExclusions(exclusionsPath string) ([]*finder.Exclusion, error) {
+	if exclusionsPath == "" {
+		return nil, nil
+	}
+	return finder.LoadExclusionsFile(exclusionsPath)
+}
+
+func innerMain(logger *log.Logger, req *api.CrosTestFinderRequest, metadataDir string, exclusions []*finder.Exclusion) (*api.CrosTestFinderResponse, error) {
 	logger.Println("Reading metadata from directory: ", metadataDir)
 	allTestMetadata, err := metadata.ReadDir(metadataDir)
 	if err != nil {
@@ -144,11 +155,14 @@ func innerMain(logger *log.Logger, req *api.CrosTestFinderRequest, metadataDir s
 
 	suiteName := combineTestSuiteNames(req.TestSuites)
 
-	selectedTestMetadata, err := finder.MatchedTestsForSuites(allTestMetadata.Values, req.TestSuites)
+	selectedTestMetadata, exclusionHits, err := finder.MatchedTestsForSuites(allTestMetadata.Values, req.TestSuites, exclusions)
 	if err != nil {
 		logger.Println("Error: ", err)
 		return nil, err
 	}
+	for _, hit := range exclusionHits {
+		logger.Printf("Excluding test %v (%v): %v", hit.TestID, hit.ExclusionType, hit.Reason)
+	}
 
 	resultTestSuite := metadataToTestSuite(suiteName, selectedTestMetadata)
 
@@ -171,6 +185,7 @@ func runCLI(ctx context.Context, d []string) int {
 	fs.StringVar(&a.inputPath, "input", defaultRequestFile, "specify the test finder request json input file")
 	fs.StringVar(&a.output, "output", defaultResultFile, "specify the test finder request json input file")
 	fs.StringVar(&a.metadataDir, "metadatadir", defaultTestMetadataDir, "specify a directory that contain all test metadata proto files.")
+	fs.StringVar(&a.exclusionsPath, "exclusions", "", "specify a sidecar JSON file of finder.Exclusions to drop from the matched tests.")
 	fs.BoolVar(&a.version, "version", false, "print version and exit")
 	fs.Parse(d)
 
@@ -196,7 +211,13 @@ func runCLI(ctx context.Context, d []string) int {
 		return errors.WriteError(os.Stderr, err)
 	}
 
-	rspn, err := innerMain(logger, req, a.metadataDir)
+	exclusions, err := loadExclusions(a.exclusionsPath)
+	if err != nil {
+		logger.Println("Error: ", err)
+		return errors.WriteError(os.Stderr, err)
+	}
+
+	rspn, err := innerMain(logger, req, a.metadataDir, exclusions)
 	if err != nil {
 		return 2
 	}
@@ -218,6 +239,7 @@ func startServer(d []string) int {
 	fs := flag.NewFlagSet("Run cros-test", flag.ExitOnError)
 	fs.StringVar(&a.logPath, "log", defaultLogPath, fmt.Sprintf("Path to record finder logs. Default value is %s", defaultLogPath))
 	fs.StringVar(&a.metadataDir, "metadatadir", defaultTestMetadataDir, "specify a directory that contain all test metadata proto files.")
+	fs.StringVar(&a.exclusionsPath, "exclusions", "", "specify a sidecar JSON file of finder.Exclusions to drop from the matched tests.")
 	fs.IntVar(&a.port, "port", defaultPort, fmt.Sprintf("Specify the port for the server. Default value %d.", defaultPort))
 	fs.Parse(d)
 
@@ -230,6 +252,12 @@ func startServer(d []string) int {
 
 	logger := newLogger(logFile)
 
+	exclusions, err := loadExclusions(a.exclusionsPath)
+	if err != nil {
+		logger.Fatalln("Failed to load exclusions file: ", err)
+		return 2
+	}
+
 	l, err := net.Listen("tcp", fmt.Sprintf(":%d", a.port))
 	if err != nil {
 		logger.Fatalln("Failed to create a net listener: ", err)
@@ -237,7 +265,7 @@ func startServer(d []string) int {
 	}
 	logger.Println("Starting TestFinderService on port ", a.port)
 
-	server, closer := NewServer(logger, a.metadataDir)
+	server, closer := NewServer(logger, a.metadataDir, exclusions)
 	defer closer()
 	err = server.Serve(l)
 	if err != nil {
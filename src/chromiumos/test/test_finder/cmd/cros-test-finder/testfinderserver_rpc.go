@@ -11,20 +11,24 @@ import (
 	"go.chromium.org/chromiumos/config/go/test/api"
 	"go.chromium.org/luci/common/errors"
 	"google.golang.org/grpc"
+
+	"chromiumos/test/util/finder"
 )
 
 // TestFinderServiceServer implementation of dut_service.proto
 type TestFinderServiceServer struct {
 	logger      *log.Logger
 	metadatadir string
+	exclusions  []*finder.Exclusion
 }
 
 // NewServer creates an execution server.
-func NewServer(logger *log.Logger, metadatadir string) (*grpc.Server, func()) {
+func NewServer(logger *log.Logger, metadatadir string, exclusions []*finder.Exclusion) (*grpc.Server, func()) {
 	s := &TestFinderServiceServer{
 		logger: logger,
 
 		metadatadir: metadatadir,
+		exclusions:  exclusions,
 	}
 
 	server := grpc.NewServer()
@@ -45,7 +49,7 @@ func NewServer(logger *log.Logger, metadatadir string) (*grpc.Server, func()) {
 func (s *TestFinderServiceServer) FindTests(ctx context.Context, req *api.CrosTestFinderRequest) (*api.CrosTestFinderResponse, error) {
 	s.logger.Println("Received api.CacheRequest: ", req)
 
-	rspn, err := innerMain(s.logger, req, s.metadatadir)
+	rspn, err := innerMain(s.logger, req, s.metadatadir, s.exclusions)
 	if err != nil {
 		return nil, errors.Annotate(err, "FindTests: failed to find tests").Err()
 	}
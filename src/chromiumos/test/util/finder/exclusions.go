@@ -0,0 +1,155 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package finder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.chromium.org/chromiumos/config/go/test/api"
+)
+
+// ExclusionType mirrors the plan-v1 Exclusion.Type enum: whether a test is
+// dropped from selection for good, or only while a known-flaky/new test is
+// being stabilized.
+type ExclusionType int
+
+const (
+	ExclusionTypeUnspecified ExclusionType = iota
+	ExclusionTypePermanent
+	ExclusionTypeTemporaryNewTestStabilization
+)
+
+func (t ExclusionType) String() string {
+	switch t {
+	case ExclusionTypePermanent:
+		return "PERMANENT"
+	case ExclusionTypeTemporaryNewTestStabilization:
+		return "TEMPORARY_NEW_TEST_STABILIZATION"
+	default:
+		return "UNSPECIFIED"
+	}
+}
+
+// MarshalJSON renders t as its String() name, so exclusions sidecar files
+// are human-readable/writable instead of carrying raw enum ints.
+func (t ExclusionType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON parses t from its String() name.
+func (t *ExclusionType) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		return err
+	}
+	switch name {
+	case "", "UNSPECIFIED":
+		*t = ExclusionTypeUnspecified
+	case "PERMANENT":
+		*t = ExclusionTypePermanent
+	case "TEMPORARY_NEW_TEST_STABILIZATION":
+		*t = ExclusionTypeTemporaryNewTestStabilization
+	default:
+		return fmt.Errorf("unknown exclusion type %q", name)
+	}
+	return nil
+}
+
+// Exclusion drops any test matching TestNamePattern (a filepath.Match glob
+// against the test id, same semantics as TestNameExcludes) or TagExpression
+// (a comma-separated list of tags that must ALL be present on the test) from
+// the result of MatchedTestsForSuites, modeled on the plan-v1 Exclusion
+// message.
+type Exclusion struct {
+	TestNamePattern string        `json:"test_name_pattern,omitempty"`
+	TagExpression   string        `json:"tag_expression,omitempty"`
+	Type            ExclusionType `json:"type"`
+	Reason          string        `json:"reason,omitempty"`
+	// ExpiryDate is required for TEMPORARY_NEW_TEST_STABILIZATION exclusions.
+	// Once it has passed, MatchedTestsForSuites errors instead of silently
+	// continuing to drop the test, to force re-triage.
+	ExpiryDate time.Time `json:"expiry_date,omitempty"`
+}
+
+// LoadExclusionsFile reads a sidecar file of Exclusions, formatted as a JSON
+// array of objects shaped like Exclusion's json tags, e.g.:
+//
+//	[{"test_name_pattern": "tast.foo.*", "type": "PERMANENT", "reason": "flaky"}]
+//
+// Callers pass the result straight through to MatchedTestsForSuites.
+func LoadExclusionsFile(path string) ([]*Exclusion, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read exclusions file %q: %w", path, err)
+	}
+	var exclusions []*Exclusion
+	if err := json.Unmarshal(data, &exclusions); err != nil {
+		return nil, fmt.Errorf("unmarshal exclusions file %q: %w", path, err)
+	}
+	return exclusions, nil
+}
+
+// ExclusionHit records that a candidate test was dropped from the result by
+// an Exclusion, so callers can log why a test was skipped.
+type ExclusionHit struct {
+	TestID        string
+	ExclusionType ExclusionType
+	Reason        string
+}
+
+// validate checks that e carries the information its Type requires.
+func (e *Exclusion) validate(now time.Time) error {
+	switch e.Type {
+	case ExclusionTypePermanent:
+		if e.Reason == "" {
+			return fmt.Errorf("PERMANENT exclusion for %q must have a non-empty reason", e.pattern())
+		}
+	case ExclusionTypeTemporaryNewTestStabilization:
+		if e.ExpiryDate.IsZero() {
+			return fmt.Errorf("TEMPORARY_NEW_TEST_STABILIZATION exclusion for %q must have an expiry date", e.pattern())
+		}
+		if !e.ExpiryDate.After(now) {
+			return fmt.Errorf("TEMPORARY_NEW_TEST_STABILIZATION exclusion for %q expired on %v; re-triage and either drop it or make it PERMANENT", e.pattern(), e.ExpiryDate)
+		}
+	default:
+		return fmt.Errorf("exclusion for %q has unspecified type", e.pattern())
+	}
+	return nil
+}
+
+func (e *Exclusion) pattern() string {
+	if e.TestNamePattern != "" {
+		return e.TestNamePattern
+	}
+	return e.TagExpression
+}
+
+// matches reports whether e excludes the test described by md.
+func (e *Exclusion) matches(md *api.TestCaseMetadata) bool {
+	if e.TestNamePattern != "" {
+		if matched, _ := filepath.Match(e.TestNamePattern, md.TestCase.Id.Value); matched {
+			return true
+		}
+	}
+	if e.TagExpression != "" {
+		required := strings.Split(e.TagExpression, ",")
+		present := make(map[string]struct{}, len(md.TestCase.Tags))
+		for _, tag := range md.TestCase.Tags {
+			present[tag.Value] = struct{}{}
+		}
+		for _, tag := range required {
+			if _, ok := present[strings.TrimSpace(tag)]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+// Copyright 2023 The ChromiumOS Authors
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+package finder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestLoadExclusionsFile makes sure LoadExclusionsFile parses a sidecar JSON
+// file into the expected Exclusions.
+func TestLoadExclusionsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exclusions.json")
+	contents := `[
+		{"test_name_pattern": "tast.test.002", "type": "PERMANENT", "reason": "crbug/1234: flaky on eve"},
+		{"tag_expression": "group:mainline", "type": "TEMPORARY_NEW_TEST_STABILIZATION", "reason": "new test", "expiry_date": "2099-01-01T00:00:00Z"}
+	]`
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("Failed to write exclusions file: %v", err)
+	}
+
+	exclusions, err := LoadExclusionsFile(path)
+	if err != nil {
+		t.Fatalf("LoadExclusionsFile(%q) failed: %v", path, err)
+	}
+	if len(exclusions) != 2 {
+		t.Fatalf("len(exclusions) = %d, want 2", len(exclusions))
+	}
+
+	if exclusions[0].TestNamePattern != "tast.test.002" || exclusions[0].Type != ExclusionTypePermanent || exclusions[0].Reason == "" {
+		t.Errorf("exclusions[0] = %+v, want a PERMANENT exclusion for tast.test.002", exclusions[0])
+	}
+
+	wantExpiry := time.Date(2099, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if exclusions[1].TagExpression != "group:mainline" || exclusions[1].Type != ExclusionTypeTemporaryNewTestStabilization || !exclusions[1].ExpiryDate.Equal(wantExpiry) {
+		t.Errorf("exclusions[1] = %+v, want a TEMPORARY_NEW_TEST_STABILIZATION exclusion for group:mainline expiring %v", exclusions[1], wantExpiry)
+	}
+}
+
+// TestLoadExclusionsFileMissing makes sure LoadExclusionsFile errors out for
+// a path that doesn't exist, rather than returning an empty exclusion list.
+func TestLoadExclusionsFileMissing(t *testing.T) {
+	if _, err := LoadExclusionsFile(filepath.Join(t.TempDir(), "does-not-exist.json")); err == nil {
+		t.Fatal("Expected an error for a missing exclusions file")
+	}
+}
@@ -8,6 +8,7 @@ package finder
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"go.chromium.org/chromiumos/config/go/test/api"
 )
@@ -76,8 +77,19 @@ func (tm *tagMatcher) match(md *api.TestCaseMetadata) bool {
 	return len(matchedTags) == len(tm.tags) && matchTestNames
 }
 
-// MatchedTestsForSuites finds all test metadata that match the specified suites.
-func MatchedTestsForSuites(metadataList []*api.TestCaseMetadata, suites []*api.TestSuite) (tmList []*api.TestCaseMetadata, err error) {
+// MatchedTestsForSuites finds all test metadata that match the specified
+// suites, then drops any result matched by exclusions, recording each drop
+// as an ExclusionHit so callers can log why a test was skipped. It returns
+// an error if any exclusion is invalid (see Exclusion.validate), or if any
+// suite test id has no corresponding metadata.
+func MatchedTestsForSuites(metadataList []*api.TestCaseMetadata, suites []*api.TestSuite, exclusions []*Exclusion) (tmList []*api.TestCaseMetadata, hits []*ExclusionHit, err error) {
+	now := time.Now()
+	for _, e := range exclusions {
+		if err := e.validate(now); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	tests := make(map[string]struct{})
 	var tagMatchers []*tagMatcher
 	for _, s := range suites {
@@ -97,6 +109,15 @@ func MatchedTestsForSuites(metadataList []*api.TestCaseMetadata, suites []*api.T
 		// Get all the metadata for matched tests.
 		for _, tm := range metadataList {
 			if _, ok := tests[tm.TestCase.Id.Value]; ok {
+				if e := firstMatchingExclusion(exclusions, tm); e != nil {
+					hits = append(hits, &ExclusionHit{
+						TestID:        tm.TestCase.Id.Value,
+						ExclusionType: e.Type,
+						Reason:        e.Reason,
+					})
+					delete(tests, tm.TestCase.Id.Value)
+					continue
+				}
 				tmList = append(tmList, tm)
 				delete(tests, tm.TestCase.Id.Value)
 			}
@@ -111,7 +132,7 @@ func MatchedTestsForSuites(metadataList []*api.TestCaseMetadata, suites []*api.T
 		}
 	}()
 	if len(tagMatchers) == 0 {
-		return tmList, nil
+		return tmList, hits, nil
 	}
 	for _, tm := range metadataList {
 		if _, ok := tests[tm.TestCase.Id.Value]; ok {
@@ -126,5 +147,16 @@ func MatchedTestsForSuites(metadataList []*api.TestCaseMetadata, suites []*api.T
 		}
 	}
 
-	return tmList, nil
+	return tmList, hits, nil
+}
+
+// firstMatchingExclusion returns the first Exclusion that drops md, or nil
+// if none do.
+func firstMatchingExclusion(exclusions []*Exclusion, md *api.TestCaseMetadata) *Exclusion {
+	for _, e := range exclusions {
+		if e.matches(md) {
+			return e
+		}
+	}
+	return nil
 }
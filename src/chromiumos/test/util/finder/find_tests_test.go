@@ -6,6 +6,7 @@ package finder
 
 import (
 	"testing"
+	"time"
 
 	"go.chromium.org/chromiumos/config/go/test/api"
 )
@@ -116,7 +117,7 @@ func TestMatchedTestsForSuites(t *testing.T) {
 		"test001": false,
 		"test002": false,
 	}
-	matchedMdList, err := MatchedTestsForSuites(testMetadata, suites)
+	matchedMdList, _, err := MatchedTestsForSuites(testMetadata, suites, nil)
 	if err != nil {
 		t.Fatal("Failed to call MatchedTestsTestsForSuites: ", err)
 	}
@@ -154,7 +155,7 @@ func TestMatchedTestsForSuitesMissing(t *testing.T) {
 			},
 		},
 	}
-	if _, err := MatchedTestsForSuites(testMetadata, suites); err == nil {
+	if _, _, err := MatchedTestsForSuites(testMetadata, suites, nil); err == nil {
 		t.Fatal("Failed to get error while calling  MatchedTestsTestsForSuites with non-existing test case")
 	}
 }
@@ -178,7 +179,7 @@ func TestMatchedTestsForTestNameInSuites(t *testing.T) {
 		"tast.test.001": false,
 		"tast.test.002": false,
 	}
-	matchedMdList, err := MatchedTestsForSuites(testMetadata, suites)
+	matchedMdList, _, err := MatchedTestsForSuites(testMetadata, suites, nil)
 	if err != nil {
 		t.Fatal("Failed to call MatchedTestsTestsForSuites: ", err)
 	}
@@ -221,7 +222,7 @@ func TestMatchedTestsForTestNameExcludesInSuites(t *testing.T) {
 	expectedTests := map[string]bool{
 		"tast.test.001": false,
 	}
-	matchedMdList, err := MatchedTestsForSuites(testMetadata, suites)
+	matchedMdList, _, err := MatchedTestsForSuites(testMetadata, suites, nil)
 	if err != nil {
 		t.Fatal("Failed to call MatchedTestsTestsForSuites: ", err)
 	}
@@ -339,3 +340,66 @@ func TestNameMatchExclude(t *testing.T) {
 		t.Fatal("tagMatcher failed to exclude a test metadata")
 	}
 }
+
+// TestMatchedTestsForSuitesExclusions makes sure a PERMANENT exclusion drops
+// the matching test and is reported as an ExclusionHit.
+func TestMatchedTestsForSuitesExclusions(t *testing.T) {
+	suites := []*api.TestSuite{
+		{
+			Name: "suite1",
+			Spec: &api.TestSuite_TestCaseTagCriteria_{
+				TestCaseTagCriteria: &api.TestSuite_TestCaseTagCriteria{
+					Tags: []string{"attr1"},
+				},
+			},
+		},
+	}
+	exclusions := []*Exclusion{
+		{
+			TestNamePattern: "tast.test.002",
+			Type:            ExclusionTypePermanent,
+			Reason:          "crbug/1234: flaky on eve",
+		},
+	}
+
+	matchedMdList, hits, err := MatchedTestsForSuites(testMetadata, suites, exclusions)
+	if err != nil {
+		t.Fatal("Failed to call MatchedTestsForSuites: ", err)
+	}
+	for _, md := range matchedMdList {
+		if md.TestCase.Id.Value == "tast.test.002" {
+			t.Errorf("tast.test.002 should have been excluded, got: %+v", md)
+		}
+	}
+	if len(hits) != 1 || hits[0].TestID != "tast.test.002" || hits[0].ExclusionType != ExclusionTypePermanent {
+		t.Errorf("Expected one ExclusionHit for tast.test.002, got: %+v", hits)
+	}
+}
+
+// TestMatchedTestsForSuitesPermanentExclusionRequiresReason makes sure a
+// PERMANENT exclusion without a reason is rejected.
+func TestMatchedTestsForSuitesPermanentExclusionRequiresReason(t *testing.T) {
+	exclusions := []*Exclusion{
+		{TestNamePattern: "tast.test.002", Type: ExclusionTypePermanent},
+	}
+	if _, _, err := MatchedTestsForSuites(testMetadata, nil, exclusions); err == nil {
+		t.Fatal("Expected an error for a PERMANENT exclusion with no reason")
+	}
+}
+
+// TestMatchedTestsForSuitesExpiredTemporaryExclusion makes sure an expired
+// TEMPORARY_NEW_TEST_STABILIZATION exclusion forces an error instead of
+// silently continuing to exclude the test.
+func TestMatchedTestsForSuitesExpiredTemporaryExclusion(t *testing.T) {
+	exclusions := []*Exclusion{
+		{
+			TestNamePattern: "tast.test.002",
+			Type:            ExclusionTypeTemporaryNewTestStabilization,
+			Reason:          "crbug/1234: new test stabilization",
+			ExpiryDate:      time.Now().Add(-time.Hour),
+		},
+	}
+	if _, _, err := MatchedTestsForSuites(testMetadata, nil, exclusions); err == nil {
+		t.Fatal("Expected an error for an expired TEMPORARY exclusion")
+	}
+}